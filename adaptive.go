@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+	"go.uber.org/zap"
+)
+
+// adaptiveInitialWorkers is the number of last invoke workers running at the same time when -adaptive-workers starts
+const adaptiveInitialWorkers = 2
+
+// adaptiveConcurrency limits how many workers call the API at the same time, adjusting the limit using
+// additive increase and multiplicative decrease (AIMD). The limit is increased by one after a full round of calls
+// without throttling, and halved when a throttle response is seen, without going above maxLimit or below one
+type adaptiveConcurrency struct {
+	logger *zap.SugaredLogger
+
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	limit    int
+	maxLimit int
+	inFlight int
+
+	// successes is the number of calls without throttling since the limit was last changed
+	successes int
+
+	// throttleCount is the total number of throttle responses, and lastThrottleCount is its value
+	// when the limit was last decreased, so that each throttle response only decreases the limit once
+	throttleCount     *atomic.Int64
+	lastThrottleCount int64
+}
+
+// newAdaptiveConcurrency creates the AIMD concurrency limiter, starting at adaptiveInitialWorkers
+// or maxLimit if it is lower. The limiter is driven by the throttle responses counted in throttleCount
+func newAdaptiveConcurrency(logger *zap.SugaredLogger, maxLimit int, throttleCount *atomic.Int64) *adaptiveConcurrency {
+	c := &adaptiveConcurrency{
+		logger:            logger,
+		limit:             max(1, min(adaptiveInitialWorkers, maxLimit)),
+		maxLimit:          max(1, maxLimit),
+		throttleCount:     throttleCount,
+		lastThrottleCount: throttleCount.Load(),
+	}
+	c.cond = sync.NewCond(&c.mu)
+
+	return c
+}
+
+// acquire blocks until the number of calls in flight is below the current limit
+func (c *adaptiveConcurrency) acquire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for c.inFlight >= c.limit {
+		c.cond.Wait()
+	}
+	c.inFlight++
+}
+
+// release marks the end of a call and adjusts the limit depending on whether any call has been throttled since
+// the limit was last decreased
+func (c *adaptiveConcurrency) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.inFlight--
+
+	throttleCount := c.throttleCount.Load()
+	if throttleCount > c.lastThrottleCount {
+		c.lastThrottleCount = throttleCount
+		c.successes = 0
+		c.setLimit(max(1, c.limit/2))
+	} else {
+		c.successes++
+		if c.successes >= c.limit && c.limit < c.maxLimit {
+			c.successes = 0
+			c.setLimit(c.limit + 1)
+		}
+	}
+
+	c.cond.Broadcast()
+}
+
+// setLimit changes the limit and logs the new value. It must be called with mu locked
+func (c *adaptiveConcurrency) setLimit(limit int) {
+	if limit == c.limit {
+		return
+	}
+
+	c.limit = limit
+	c.logger.Debugw("adjusted the number of concurrent workers",
+		zap.Int("workers", limit),
+	)
+}
+
+// getLimit returns the current limit
+func (c *adaptiveConcurrency) getLimit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.limit
+}
+
+// acquireWorkerSlot waits until the worker can make its next API call when -adaptive-workers is set,
+// and returns the function to call once the API call is done. Without -adaptive-workers, it returns immediately
+func (app *application) acquireWorkerSlot() func() {
+	if app.workerConcurrency == nil {
+		return func() {}
+	}
+
+	app.workerConcurrency.acquire()
+	return app.workerConcurrency.release
+}
+
+// addThrottleCounter adds a middleware to all AWS service clients that counts the API call attempts
+// which are throttled, including the ones that succeed after a retry
+func (app *application) addThrottleCounter(stack *middleware.Stack) error {
+	throttles := retry.IsErrorThrottles(retry.DefaultThrottles)
+
+	return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("CountThrottles",
+		func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			out, metadata, err := next.HandleFinalize(ctx, in)
+			if err != nil && throttles.IsErrorThrottle(err) == aws.TrueTernary {
+				app.throttleCount.Add(1)
+				app.addRegionThrottle(awsmiddleware.GetRegion(ctx))
+			}
+			return out, metadata, err
+		},
+	), middleware.After)
+}
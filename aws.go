@@ -2,43 +2,90 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"go.uber.org/zap"
 )
 
-// getAllAvailableRegions retrieves all available
-// (opted-in and regions where opt-in is not required) regions in the account
-func (app *application) getAllAvailableRegions() ([]string, error) {
-	app.logger.Infow("all-regions options enabled, getting all available regions")
+// getEnabledRegions retrieves all enabled regions in the account, which are the regions where opt-in
+// is not required and, if includeOptInRegions is set, the opt-in regions that the account has opted in to
+func (app *application) getEnabledRegions(ctx context.Context, includeOptInRegions bool) ([]string, error) {
+	app.logger.Infow("getting all enabled regions",
+		zap.Bool("include_opt_in_regions", includeOptInRegions),
+	)
+
+	optInStatuses := []string{"opt-in-not-required"}
+	if includeOptInRegions {
+		optInStatuses = append(optInStatuses, "opted-in")
+	}
 
 	in := &ec2.DescribeRegionsInput{
 		Filters: []types.Filter{
 			{
-				Name: aws.String("opt-in-status"),
-				Values: []string{
-					"opt-in-not-required",
-					"opted-in",
-				},
+				Name:   aws.String("opt-in-status"),
+				Values: optInStatuses,
 			},
 		},
 	}
 
-	describeRegionsOutput, err := app.ec2Client.DescribeRegions(context.Background(), in)
+	describeRegionsOutput, err := app.ec2Client.DescribeRegions(ctx, in)
 	if err != nil {
 		return nil, err
 	}
 
-	optedInRegionsList := []string{}
+	enabledRegionsList := []string{}
 	for _, region := range describeRegionsOutput.Regions {
-		optedInRegionsList = append(optedInRegionsList, *region.RegionName)
+		enabledRegionsList = append(enabledRegionsList, aws.ToString(region.RegionName))
 	}
 
-	app.logger.Debugw("got all available regions in the account",
-		zap.Int("region_count", len(optedInRegionsList)),
+	app.logger.Debugw("got all enabled regions in the account",
+		zap.Int("region_count", len(enabledRegionsList)),
 	)
 
-	return optedInRegionsList, nil
+	return enabledRegionsList, nil
+}
+
+// validateRegions makes sure that all chosen regions are available in the account.
+// It returns an error listing all of the invalid region names
+func validateRegions(chosenRegions []string, availableRegions []string) error {
+	invalidRegions := []string{}
+	for _, region := range chosenRegions {
+		if !slices.Contains(availableRegions, region) {
+			invalidRegions = append(invalidRegions, region)
+		}
+	}
+
+	if len(invalidRegions) > 0 {
+		return fmt.Errorf("invalid or unavailable regions: %s", strings.Join(invalidRegions, ", "))
+	}
+
+	return nil
+}
+
+// excludeRegions returns the regions that are not in the excludedRegions list
+func excludeRegions(regions []string, excludedRegions []string) []string {
+	filteredRegions := []string{}
+	for _, region := range regions {
+		if !slices.Contains(excludedRegions, region) {
+			filteredRegions = append(filteredRegions, region)
+		}
+	}
+
+	return filteredRegions
+}
+
+// getAccountId returns the ID of the AWS account of the credentials in use
+func getAccountId(ctx context.Context, stsClient callerIdentityGetter) (string, error) {
+	out, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+
+	return aws.ToString(out.Account), nil
 }
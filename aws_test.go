@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// fakeRegionDescriber returns the regions which opt-in status matches the opt-in-status filter of the request,
+// like DescribeRegions does, or err if it is set
+type fakeRegionDescriber struct {
+	regions []types.Region
+	err     error
+}
+
+func (f fakeRegionDescriber) DescribeRegions(ctx context.Context, params *ec2.DescribeRegionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeRegionsOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	var optInStatuses []string
+	for _, filter := range params.Filters {
+		if aws.ToString(filter.Name) == "opt-in-status" {
+			optInStatuses = filter.Values
+		}
+	}
+
+	regions := []types.Region{}
+	for _, region := range f.regions {
+		if optInStatuses == nil || slices.Contains(optInStatuses, aws.ToString(region.OptInStatus)) {
+			regions = append(regions, region)
+		}
+	}
+
+	return &ec2.DescribeRegionsOutput{Regions: regions}, nil
+}
+
+func TestGetEnabledRegions(t *testing.T) {
+	describer := fakeRegionDescriber{
+		regions: []types.Region{
+			{RegionName: aws.String("us-east-1"), OptInStatus: aws.String("opt-in-not-required")},
+			{RegionName: aws.String("eu-west-1"), OptInStatus: aws.String("opt-in-not-required")},
+			{RegionName: aws.String("ap-east-1"), OptInStatus: aws.String("opted-in")},
+			{RegionName: aws.String("me-south-1"), OptInStatus: aws.String("not-opted-in")},
+		},
+	}
+
+	tests := []struct {
+		name                string
+		includeOptInRegions bool
+		want                []string
+	}{
+		{name: "without opt-in regions", want: []string{"us-east-1", "eu-west-1"}},
+		{name: "with opt-in regions", includeOptInRegions: true, want: []string{"us-east-1", "eu-west-1", "ap-east-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication()
+			app.ec2Client = describer
+
+			got, err := app.getEnabledRegions(context.Background(), tt.includeOptInRegions)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("getEnabledRegions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	app := newTestApplication()
+	app.ec2Client = fakeRegionDescriber{err: errors.New("access denied")}
+	if _, err := app.getEnabledRegions(context.Background(), false); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestValidateRegions(t *testing.T) {
+	availableRegions := []string{"us-east-1", "eu-west-1"}
+
+	if err := validateRegions([]string{"eu-west-1"}, availableRegions); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err := validateRegions([]string{"us-east-1", "me-south-1", "xx-invalid-1"}, availableRegions)
+	if err == nil || err.Error() != "invalid or unavailable regions: me-south-1, xx-invalid-1" {
+		t.Errorf("validateRegions() = %v, want the invalid regions listed", err)
+	}
+}
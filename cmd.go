@@ -1,9 +1,12 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -12,15 +15,18 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"github.com/aws/smithy-go"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
 // job contains the required information for a worker goroutines
-// to be able to query the Lambda function last invocation time
+// to be able to query the Lambda function additional details (e.g. last invocation time or tags)
 // and writes the result back to the Lambda function slice
 type job struct {
 	functionName string
+	functionArn  string
 	region       string
 	index        int
 }
@@ -28,72 +34,198 @@ type job struct {
 const (
 	lambdaLogGroupPrefix = "/aws/lambda/"
 
+	// lastInvokedTimeFormat is the format of the LastInvoked field
+	lastInvokedTimeFormat = "2006-01-02T15:04:05-07:00"
+
+	// lastInvokedSkipped is the LastInvoked value of all functions when -skip-last-invoke is set
+	lastInvokedSkipped = "n/a"
+
+	lastInvokeSourceLogs    = "logs"
+	lastInvokeSourceMetrics = "metrics"
+
 	cloudWatchLogGroupDoesNotExistErrorMessage = "The specified log group does not exist"
+
+	// maxListPageSize is the maximum MaxItems of ListFunctions
+	maxListPageSize = 10000
 )
 
+// regionResult contains the Lambda functions listed in a region, or the error encountered when listing them
+type regionResult struct {
+	region              string
+	lambdaFunctionsList []lambdaFunction
+	err                 error
+}
+
 // getAllLambdaFunctionsDetails returns slice containing the details of all
-// Lambda functions in the region specified by regions parameter
-func (app *application) getAllLambdaFunctionsDetails() ([]lambdaFunction, error) {
+// Lambda functions in the region specified by regions parameter.
+//
+// Each region is listed in its own goroutine, with at most maxWorkers regions being listed at the same time.
+// The results are consumed from a channel and the resulting slice is sorted by region then function name,
+// so that the output is deterministic.
+// If there's an error, the details gathered so far are returned together with the first error.
+// Access denied errors are only recorded, so that the other regions are still listed, unless -fail-on-access-denied is set
+func (app *application) getAllLambdaFunctionsDetails(ctx context.Context, maxWorkers int) ([]lambdaFunction, error) {
 	app.logger.Info("getting function details for lambda functions")
 
-	var lambdaFunctionsList []lambdaFunction
-	in := &lambda.ListFunctionsInput{}
+	results := make(chan regionResult)
+	semaphore := make(chan struct{}, maxWorkers)
+	wg := &sync.WaitGroup{}
 
-	for _, lambdaClient := range app.lambdaClients {
-		app.logger.Debugw("getting Lambda functions",
-			zap.String("current_region", lambdaClient.Options().Region),
-		)
+	for _, region := range app.regions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			startTime := time.Now()
 
-		for {
-			out, err := lambdaClient.ListFunctions(context.Background(), in)
-			if err != nil {
-				return nil, err
+			regionFunctionsList, err := app.getLambdaFunctionsDetails(ctx, region, app.lambdaClients[region])
+
+			app.logger.Debugw("got lambda functions in region",
+				zap.String("region", region),
+				zap.Int("function_count", len(regionFunctionsList)),
+				zap.Duration("elapsed", time.Since(startTime)),
+			)
+
+			results <- regionResult{
+				region:              region,
+				lambdaFunctionsList: regionFunctionsList,
+				err:                 err,
 			}
+		}()
+	}
 
-			for _, functionDetail := range out.Functions {
-				f := lambdaFunction{
-					Name:         *functionDetail.FunctionName,
-					Region:       lambdaClient.Options().Region,
-					Arn:          *functionDetail.FunctionArn,
-					Description:  *functionDetail.Description,
-					LastModified: *functionDetail.LastModified,
-					IamRole:      *functionDetail.Role,
-					Runtime:      string(functionDetail.Runtime),
-				}
+	// close the results channel once all regions are done, so that the loop below ends
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	fatalErrorCodes := parseCommaSeparatedList(app.stg.fatalErrorCodes)
+
+	var lambdaFunctionsList []lambdaFunction
+	var firstErr error
+	for result := range results {
+		lambdaFunctionsList = append(lambdaFunctionsList, result.lambdaFunctionsList...)
+		if result.err != nil {
+			app.recordError(result.region, "", "ListFunctions", result.err)
 
-				lambdaFunctionsList = append(lambdaFunctionsList, f)
+			// a region where listing the functions is denied is skipped, unless -fail-on-access-denied is set
+			if isAccessDenied(result.err) && !app.stg.failOnAccessDenied {
+				app.logger.Warnw("access denied when listing functions, skipping the region",
+					zap.String("region", result.region),
+					zap.Error(result.err),
+				)
+				continue
 			}
 
-			if out.NextMarker != nil {
-				in.Marker = out.NextMarker
+			// a region that is disabled after the regions are listed is skipped, since the other regions can still be listed.
+			// The fatal errors, e.g. invalid credentials, would fail all regions the same way, so they stop the run instead
+			if !isFatalError(result.err, fatalErrorCodes) && isRegionUnavailable(result.err) {
+				app.logger.Warnw("the region is not enabled or its endpoint can't be resolved, skipping the region",
+					zap.String("region", result.region),
+					zap.Error(result.err),
+				)
 				continue
-			} else {
-				break
+			}
+
+			if firstErr == nil {
+				firstErr = result.err
 			}
 		}
 	}
 
+	slices.SortFunc(lambdaFunctionsList, func(a, b lambdaFunction) int {
+		return cmp.Or(
+			cmp.Compare(a.Region, b.Region),
+			cmp.Compare(a.Name, b.Name),
+			compareVersions(a.Version, b.Version),
+		)
+	})
+
 	app.logger.Infow("got all lambda function details",
 		zap.Int("function_count", len(lambdaFunctionsList)),
 	)
 
+	return lambdaFunctionsList, firstErr
+}
+
+// getLambdaFunctionsDetails returns slice containing the details of all
+// Lambda functions in the region, using the lambdaClient of that region.
+// If there's an error, the details gathered so far are returned together with the error
+func (app *application) getLambdaFunctionsDetails(ctx context.Context, region string, lambdaClient lambdaLister) ([]lambdaFunction, error) {
+	app.logger.Debugw("getting Lambda functions",
+		zap.String("current_region", region),
+	)
+
+	var lambdaFunctionsList []lambdaFunction
+	in := &lambda.ListFunctionsInput{
+		MaxItems: aws.Int32(int32(app.stg.listPageSize)),
+	}
+	if app.stg.includeVersions {
+		in.FunctionVersion = lambdatypes.FunctionVersionAll
+	}
+
+	for {
+		out, err := lambdaClient.ListFunctions(ctx, in)
+		if err != nil {
+			return lambdaFunctionsList, fmt.Errorf("error when listing functions in region %s: %w", region, err)
+		}
+
+		for _, functionDetail := range out.Functions {
+			f := newLambdaFunction(functionDetail, region)
+			f.AccountId = app.accountId
+			f.Profile = app.profileName
+
+			lambdaFunctionsList = append(lambdaFunctionsList, f)
+		}
+
+		if out.NextMarker != nil {
+			in.Marker = out.NextMarker
+			continue
+		} else {
+			break
+		}
+	}
+
 	return lambdaFunctionsList, nil
 }
 
-// generateLastInvokeTimeQueryJob generates job channel. This channel will be consumed by
-// getLambdaFunctionLastInvokeTime function
-func (app *application) generateLastInvokeTimeQueryJob(lambdaFunctionsList []lambdaFunction, maxWorkers int) <-chan job {
+// validateListPageSize makes sure that the ListFunctions page size is within the range accepted by the API
+func validateListPageSize(listPageSize int) error {
+	if listPageSize < 1 || listPageSize > maxListPageSize {
+		return fmt.Errorf("invalid list page size %d, it must be between 1 and %d", listPageSize, maxListPageSize)
+	}
+
+	return nil
+}
+
+// generateJobs generates job channel. This channel will be consumed by
+// worker functions such as getLambdaFunctionLastInvokeTime and getLambdaFunctionTags.
+//
+// The jobs are sent from a separate goroutine so that the workers can start draining the
+// channel right away, regardless of the number of functions
+func (app *application) generateJobs(ctx context.Context, lambdaFunctionsList []lambdaFunction) <-chan job {
 	jobs := make(chan job)
 
 	go func() {
 		for i, lambdaDetails := range lambdaFunctionsList {
 			currentJob := job{
 				functionName: lambdaDetails.Name,
+				functionArn:  lambdaDetails.Arn,
 				region:       lambdaDetails.Region,
 				index:        i,
 			}
 
-			jobs <- currentJob
+			// stop sending jobs when the context is cancelled
+			select {
+			case jobs <- currentJob:
+			case <-ctx.Done():
+				close(jobs)
+				return
+			}
 		}
 		close(jobs)
 	}()
@@ -101,81 +233,476 @@ func (app *application) generateLastInvokeTimeQueryJob(lambdaFunctionsList []lam
 	return jobs
 }
 
-// getAllLambdaFunctionsLastInvokeTime wraps getLambdaFunctionLastInvokeTime and invoke them concurrently in the background.
-func (app *application) getAllLambdaFunctionsLastInvokeTime(lambdaFunctionsList []lambdaFunction, jobs <-chan job, maxWorkers int) {
-	app.logger.Info("getting last invoke time for all lambda functions")
+// perFunctionCall gets the details of the Lambda function of the job. It returns the function that writes the details
+// in the Lambda function, which is nil if there's nothing to write, e.g. when the function is skipped
+type perFunctionCall func(ctx context.Context, currentJob job) (apply func(lambdaDetails *lambdaFunction), err error)
 
-	wg := &sync.WaitGroup{}
+// perFunctionResult is the result of perFunctionCall for the Lambda function at index in the lambdaFunctionsList slice.
+// skipped is set for the functions that are not called because of a fatal error of another function
+type perFunctionResult struct {
+	index   int
+	apply   func(lambdaDetails *lambdaFunction)
+	err     error
+	skipped bool
+}
 
-	for range maxWorkers {
-		wg.Add(1)
-		go app.getLambdaFunctionLastInvokeTime(jobs, lambdaFunctionsList, wg)
+// runPerFunction calls call for the Lambda function of each job, with up to maxWorkers functions at the same time.
+// The results are written in the lambdaFunctionsList slice by the calling goroutine only, and the errors are recorded
+// with operation. An error which code is one of -fatal-error-codes, e.g. expired credentials, stops the remaining
+// functions, and the functions resolved so far are kept. If resolved is not nil, it is called with the index of every
+// function once its result is collected, including the functions with an error and the skipped functions
+func (app *application) runPerFunction(ctx context.Context, lambdaFunctionsList []lambdaFunction, jobs <-chan job, maxWorkers int, operation string, call perFunctionCall, resolved func(index int)) {
+	fatalErrorCodes := parseCommaSeparatedList(app.stg.fatalErrorCodes)
+
+	g, groupCtx := errgroup.WithContext(ctx)
+	g.SetLimit(maxWorkers)
+	results := make(chan perFunctionResult)
+
+	// fatalErr is only read after results is closed
+	var fatalErr error
+	go func() {
+		for currentJob := range jobs {
+			// keep receiving the remaining jobs after a fatal error, so that generateJobs is not blocked
+			if groupCtx.Err() != nil {
+				results <- perFunctionResult{index: currentJob.index, skipped: true}
+				continue
+			}
+
+			g.Go(func() error {
+				// the result is always sent, also with an error, so that the collector sees every function
+				apply, err := call(groupCtx, currentJob)
+				results <- perFunctionResult{index: currentJob.index, apply: apply, err: err}
+
+				if err != nil && isFatalError(err, fatalErrorCodes) {
+					return fmt.Errorf("error when calling %s for function %q: %w", operation, currentJob.functionName, err)
+				}
+				return nil
+			})
+		}
+
+		fatalErr = g.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		lambdaDetails := &lambdaFunctionsList[result.index]
+
+		switch {
+		case result.skipped:
+		case result.err == nil:
+			if result.apply != nil {
+				result.apply(lambdaDetails)
+			}
+		// the calls cancelled because of a fatal error of another function are not errors on their own
+		case errors.Is(result.err, context.Canceled) && groupCtx.Err() != nil && ctx.Err() == nil:
+		default:
+			app.logger.Debugw("error when getting function details",
+				zap.String("operation", operation),
+				zap.String("function_name", lambdaDetails.Name),
+				zap.Error(result.err),
+			)
+			app.recordError(lambdaDetails.Region, lambdaDetails.Name, operation, result.err)
+		}
+
+		if resolved != nil {
+			resolved(result.index)
+		}
+	}
+
+	if fatalErr != nil {
+		app.logger.Errorw("stopped calling "+operation+" because of a fatal error, continuing with partial results",
+			zap.Error(fatalErr),
+		)
+	}
+}
+
+// getAllLambdaFunctionsLastInvokeTime wraps getLambdaFunctionLastInvokeTime or getLambdaFunctionLastInvokeTimeFromMetrics,
+// depending on the chosen last invoke source, and invoke them concurrently in the background with up to maxWorkers
+// functions at the same time using runPerFunction
+func (app *application) getAllLambdaFunctionsLastInvokeTime(ctx context.Context, lambdaFunctionsList []lambdaFunction, jobs <-chan job, maxWorkers int) {
+	app.logger.Infow("getting last invoke time for all lambda functions",
+		zap.String("last_invoke_source", app.stg.lastInvokeSource),
+	)
+
+	getLastInvokeTime := app.getLambdaFunctionLastInvokeTime
+	operation := "DescribeLogStreams"
+	if app.stg.lastInvokeSource == lastInvokeSourceMetrics {
+		getLastInvokeTime = app.getLambdaFunctionLastInvokeTimeFromMetrics
+		operation = "GetMetricData"
 	}
 
-	wg.Wait()
+	// report the progress periodically until all workers are done
+	done := make(chan struct{})
+	logOutput := os.Stdout
+	if logsToStderr(app.stg) {
+		logOutput = os.Stderr
+	}
+	if app.stg.progressInterval > 0 && isTerminal(logOutput) {
+		go app.reportLastInvokeProgress(len(lambdaFunctionsList), app.stg.progressInterval, done)
+	}
+
+	if app.stg.adaptiveWorkers {
+		app.workerConcurrency = newAdaptiveConcurrency(app.logger, maxWorkers, &app.throttleCount)
+	}
+
+	app.runPerFunction(ctx, lambdaFunctionsList, jobs, maxWorkers, operation, func(ctx context.Context, currentJob job) (func(*lambdaFunction), error) {
+		result := getLastInvokeTime(ctx, currentJob)
+		if result.err != nil {
+			return nil, result.err
+		}
+
+		return func(lambdaDetails *lambdaFunction) {
+			lambdaDetails.LastInvoked = result.lastInvoked
+			if result.invokeCount != "" {
+				lambdaDetails.InvokeCount = result.invokeCount
+			}
+		}, nil
+	}, app.lastInvokeResolved)
+
+	close(done)
+	if app.workerConcurrency != nil {
+		app.logger.Debugw("adaptive workers finished",
+			zap.Int("workers", app.workerConcurrency.getLimit()),
+			zap.Int64("throttled_api_calls", app.throttleCount.Load()),
+		)
+	}
 	app.logger.Info("got last invoke time for all lambda functions")
 }
 
+// lastInvokeResult is the last invoke time of the Lambda function of a job, returned by the last invoke workers.
+// The invoke count is only set when the last invoke time is taken from the metrics.
+// If err is not nil, the last invoke time couldn't be resolved
+type lastInvokeResult struct {
+	lastInvoked string
+	invokeCount string
+	err         error
+}
+
 // getLambdaFunctionLastInvokeTime queries CloudWatch logs to retrieve the latest log timestamp
-// of the Lambda function which name is obtained from jobs channel
-// and write the output in the lambdaFunctionsList slice. If there's an error when describing the
-// CloudWatch log group and log stream, the resulting last invocation timestamp is "-"
-func (app *application) getLambdaFunctionLastInvokeTime(jobs <-chan job, lambdaFunctionsList []lambdaFunction, wg *sync.WaitGroup) {
-	defer wg.Done()
+// of the Lambda function of the job. If the log group or log stream doesn't exist,
+// the resulting last invocation timestamp is "-"
+func (app *application) getLambdaFunctionLastInvokeTime(ctx context.Context, currentJob job) lastInvokeResult {
+	result := lastInvokeResult{lastInvoked: "-"}
 
-	for currentJob := range jobs {
-		logGroupName := fmt.Sprintf("%s%s", lambdaLogGroupPrefix, currentJob.functionName)
+	logGroupName := fmt.Sprintf("%s%s", lambdaLogGroupPrefix, currentJob.functionName)
 
-		input := &cloudwatchlogs.DescribeLogStreamsInput{
-			LogGroupName: aws.String(logGroupName),
-			Descending:   aws.Bool(false),
-			Limit:        aws.Int32(1),
-			OrderBy:      types.OrderByLastEventTime,
+	// the log streams are ordered from the most recent event, so the first stream holds the last invoke time
+	input := &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName: aws.String(logGroupName),
+		Descending:   aws.Bool(true),
+		Limit:        aws.Int32(1),
+		OrderBy:      types.OrderByLastEventTime,
+	}
+
+	cwLogsClient := app.cwLogsClients[currentJob.region]
+
+	var out *cloudwatchlogs.DescribeLogStreamsOutput
+	release := app.acquireWorkerSlot()
+	err := app.waitForRateLimit(ctx, currentJob.region)
+	if err == nil {
+		out, err = cwLogsClient.DescribeLogStreams(ctx, input)
+	}
+	release()
+	if err != nil {
+		var oe *smithy.OperationError
+		if errors.As(err, &oe) && oe.Operation() == "DescribeLogStreams" && strings.Contains(oe.Unwrap().Error(), cloudWatchLogGroupDoesNotExistErrorMessage) {
+			app.logger.Debugw("CloudWatch log group does not exist for lambda function",
+				zap.String("function_name", currentJob.functionName),
+			)
+		} else {
+			app.logger.Debugw("error when describing log stream",
+				zap.String("log group name", logGroupName),
+				zap.Error(err),
+			)
+			result.err = err
+		}
+	} else if len(out.LogStreams) == 0 {
+		app.logger.Debugw("no log stream exists for lambda function",
+			zap.String("function_name", currentJob.functionName),
+		)
+	} else if lastEventTimestamp, ok := getLatestEventTimestamp(out.LogStreams); ok {
+		lastEventTimestampInSeconds := lastEventTimestamp / 1000
+		t := app.inOutputTimezone(time.Unix(lastEventTimestampInSeconds, 0))
+
+		result.lastInvoked = t.Format(lastInvokedTimeFormat)
+		app.logger.Debugw("last invoke time info",
+			zap.Int64("lastEventTimestampInSeconds", lastEventTimestampInSeconds),
+			zap.String("formatted time", t.Format(lastInvokedTimeFormat)),
+			zap.String("lastInvoked", result.lastInvoked),
+		)
+	}
+
+	return result
+}
+
+// getLatestEventTimestamp returns the newest LastEventTimestamp, in milliseconds, of the log streams.
+// The second return value is false if none of the log streams has a LastEventTimestamp
+func getLatestEventTimestamp(logStreams []types.LogStream) (int64, bool) {
+	var latest int64
+	found := false
+	for _, logStream := range logStreams {
+		if logStream.LastEventTimestamp != nil && (!found || *logStream.LastEventTimestamp > latest) {
+			latest = *logStream.LastEventTimestamp
+			found = true
 		}
+	}
+
+	return latest, found
+}
+
+// getAllLambdaFunctionsLogRetention gets the log retention of all Lambda functions concurrently using runPerFunction
+func (app *application) getAllLambdaFunctionsLogRetention(ctx context.Context, lambdaFunctionsList []lambdaFunction, jobs <-chan job, maxWorkers int) {
+	app.logger.Info("getting log retention for all lambda functions")
+	app.runPerFunction(ctx, lambdaFunctionsList, jobs, maxWorkers, "DescribeLogGroups", app.getLambdaFunctionLogRetention, nil)
+	app.logger.Info("got log retention for all lambda functions")
+}
 
-		// TODO: check concurrency logic and make sure that the describe is working as intended
-		// TODO: make sure that the region used is the same for describing lambda function and describing cloudwatch logs
+// getLambdaFunctionLogRetention gets the retention of the CloudWatch log group of the Lambda function of the job.
+// Log groups that never expire are written as "never", and if the log group does not exist it is written as "-"
+func (app *application) getLambdaFunctionLogRetention(ctx context.Context, currentJob job) (func(*lambdaFunction), error) {
+	logGroupName := fmt.Sprintf("%s%s", lambdaLogGroupPrefix, currentJob.functionName)
+	cwLogsClient := app.cwLogsClients[currentJob.region]
 
-		cwLogsClient := cloudwatchlogs.NewFromConfig(*app.cfg, func(o *cloudwatchlogs.Options) {
-			o.Region = currentJob.region
-		})
+	logGroup, err := findLogGroup(ctx, cwLogsClient, logGroupName)
+	if err != nil {
+		return nil, err
+	}
 
-		out, err := cwLogsClient.DescribeLogStreams(context.Background(), input)
+	logRetentionDays := "-"
+	if logGroup == nil {
+		app.logger.Debugw("CloudWatch log group does not exist for lambda function",
+			zap.String("function_name", currentJob.functionName),
+		)
+	} else if logGroup.RetentionInDays == nil {
+		logRetentionDays = "never"
+	} else {
+		logRetentionDays = fmt.Sprint(*logGroup.RetentionInDays)
+	}
+
+	return func(lambdaDetails *lambdaFunction) {
+		lambdaDetails.LogRetentionDays = logRetentionDays
+	}, nil
+}
+
+// findLogGroup returns the log group with the exact logGroupName, or nil if it does not exist.
+// DescribeLogGroups only supports filtering by prefix, so the results are paged until the exact name is found
+func findLogGroup(ctx context.Context, cwLogsClient logGroupDescriber, logGroupName string) (*types.LogGroup, error) {
+	in := &cloudwatchlogs.DescribeLogGroupsInput{
+		LogGroupNamePrefix: aws.String(logGroupName),
+	}
+
+	for {
+		out, err := cwLogsClient.DescribeLogGroups(ctx, in)
 		if err != nil {
-			var oe *smithy.OperationError
-			if errors.As(err, &oe) {
-				if oe.Operation() == "DescribeLogStreams" && strings.Contains(oe.Unwrap().Error(), cloudWatchLogGroupDoesNotExistErrorMessage) {
-					app.logger.Debugw("CloudWatch log group does not exist for lambda function",
-						zap.String("function_name", currentJob.functionName),
-					)
-
-					lambdaFunctionsList[currentJob.index].LastInvoked = "-"
-				}
-			} else {
-				app.logger.Debugw("error when describing log stream",
-					zap.String("log group name", logGroupName),
-					zap.Error(err),
-				)
+			return nil, err
+		}
+
+		for _, logGroup := range out.LogGroups {
+			if aws.ToString(logGroup.LogGroupName) == logGroupName {
+				return &logGroup, nil
 			}
-		} else if len(out.LogStreams) == 0 {
-			app.logger.Debugw("no log stream exists for lambda function",
+		}
+
+		if out.NextToken == nil {
+			return nil, nil
+		}
+		in.NextToken = out.NextToken
+	}
+}
+
+// getAllLambdaFunctionsTags lists the tags of all Lambda functions concurrently using runPerFunction.
+// If there's an error when listing the tags of a function, its tags are left empty
+func (app *application) getAllLambdaFunctionsTags(ctx context.Context, lambdaFunctionsList []lambdaFunction, jobs <-chan job, maxWorkers int) {
+	app.logger.Info("getting tags for all lambda functions")
+	app.runPerFunction(ctx, lambdaFunctionsList, jobs, maxWorkers, "ListTags", app.getLambdaFunctionTags, nil)
+	app.logger.Info("got tags for all lambda functions")
+}
+
+// getLambdaFunctionTags lists the tags of the Lambda function of the job
+func (app *application) getLambdaFunctionTags(ctx context.Context, currentJob job) (func(*lambdaFunction), error) {
+	out, err := app.getLambdaClient(currentJob.region).ListTags(ctx, &lambda.ListTagsInput{
+		// tags can only be listed on the function, not on its versions
+		Resource: aws.String(unqualifiedFunctionArn(currentJob.functionArn)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func(lambdaDetails *lambdaFunction) {
+		lambdaDetails.Tags = out.Tags
+	}, nil
+}
+
+// getAllLambdaFunctionsImageUri gets the container image URI of the Lambda functions concurrently using runPerFunction.
+// Functions that are not container images are skipped, since the image URI is only returned by GetFunction
+func (app *application) getAllLambdaFunctionsImageUri(ctx context.Context, lambdaFunctionsList []lambdaFunction, jobs <-chan job, maxWorkers int) {
+	app.logger.Info("getting image URI for container image lambda functions")
+	app.runPerFunction(ctx, lambdaFunctionsList, jobs, maxWorkers, "GetFunction", func(ctx context.Context, currentJob job) (func(*lambdaFunction), error) {
+		if lambdaFunctionsList[currentJob.index].PackageType != string(lambdatypes.PackageTypeImage) {
+			return nil, nil
+		}
+
+		return app.getLambdaFunctionImageUri(ctx, currentJob)
+	}, nil)
+	app.logger.Info("got image URI for container image lambda functions")
+}
+
+// getLambdaFunctionImageUri gets the container image URI of the Lambda function of the job
+func (app *application) getLambdaFunctionImageUri(ctx context.Context, currentJob job) (func(*lambdaFunction), error) {
+	out, err := app.getLambdaClient(currentJob.region).GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: aws.String(currentJob.functionArn),
+	})
+	if err != nil || out.Code == nil {
+		return nil, err
+	}
+
+	return func(lambdaDetails *lambdaFunction) {
+		lambdaDetails.ImageUri = stringValueOrDefault(out.Code.ImageUri, "-")
+	}, nil
+}
+
+// getAllLambdaFunctionsDetailedConfig gets the detailed configuration of all Lambda functions concurrently using runPerFunction
+func (app *application) getAllLambdaFunctionsDetailedConfig(ctx context.Context, lambdaFunctionsList []lambdaFunction, jobs <-chan job, maxWorkers int) {
+	app.logger.Info("getting detailed configuration for all lambda functions")
+	app.runPerFunction(ctx, lambdaFunctionsList, jobs, maxWorkers, "GetFunction", app.getLambdaFunctionDetailedConfig, nil)
+	app.logger.Info("got detailed configuration for all lambda functions")
+}
+
+// getLambdaFunctionDetailedConfig gets the Lambda function of the job using GetFunction, and writes the details
+// that ListFunctions doesn't always return, which are the code SHA256, the revision ID, the signing profile,
+// the state, and the last update status. The image URI of container image functions is also written,
+// since it is returned by the same call
+func (app *application) getLambdaFunctionDetailedConfig(ctx context.Context, currentJob job) (func(*lambdaFunction), error) {
+	out, err := app.getLambdaClient(currentJob.region).GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: aws.String(currentJob.functionArn),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func(lambdaDetails *lambdaFunction) {
+		if out.Configuration != nil {
+			lambdaDetails.CodeSha256 = stringValueOrDefault(out.Configuration.CodeSha256, "-")
+			lambdaDetails.RevisionId = stringValueOrDefault(out.Configuration.RevisionId, "-")
+			lambdaDetails.SigningProfile = stringValueOrDefault(out.Configuration.SigningProfileVersionArn, "-")
+			lambdaDetails.State = enumValueOrDefault(out.Configuration.State, "-")
+			lambdaDetails.LastUpdateStatus = enumValueOrDefault(out.Configuration.LastUpdateStatus, "-")
+		}
+		if out.Code != nil && lambdaDetails.PackageType == string(lambdatypes.PackageTypeImage) {
+			lambdaDetails.ImageUri = stringValueOrDefault(out.Code.ImageUri, "-")
+		}
+	}, nil
+}
+
+// getAllLambdaFunctionsDestinations gets the destinations of all Lambda functions concurrently using runPerFunction
+func (app *application) getAllLambdaFunctionsDestinations(ctx context.Context, lambdaFunctionsList []lambdaFunction, jobs <-chan job, maxWorkers int) {
+	app.logger.Info("getting destinations for all lambda functions")
+	app.runPerFunction(ctx, lambdaFunctionsList, jobs, maxWorkers, "GetFunctionEventInvokeConfig", app.getLambdaFunctionDestinations, nil)
+	app.logger.Info("got destinations for all lambda functions")
+}
+
+// getLambdaFunctionDestinations gets the asynchronous invocation destinations of the Lambda function of the job.
+// If the function has no asynchronous invocation config, the destinations are left as "-"
+func (app *application) getLambdaFunctionDestinations(ctx context.Context, currentJob job) (func(*lambdaFunction), error) {
+	out, err := app.getLambdaClient(currentJob.region).GetFunctionEventInvokeConfig(ctx, &lambda.GetFunctionEventInvokeConfigInput{
+		FunctionName: aws.String(currentJob.functionArn),
+	})
+	if err != nil {
+		var rnf *lambdatypes.ResourceNotFoundException
+		if errors.As(err, &rnf) {
+			app.logger.Debugw("no event invoke config exists for lambda function",
 				zap.String("function_name", currentJob.functionName),
 			)
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	onSuccess, onFailure := getDestinations(out.DestinationConfig)
+	return func(lambdaDetails *lambdaFunction) {
+		lambdaDetails.OnSuccessDestination = onSuccess
+		lambdaDetails.OnFailureDestination = onFailure
+	}, nil
+}
+
+// getAllLambdaFunctionsConcurrency gets the reserved concurrency and the total provisioned concurrency of all
+// Lambda functions concurrently using runPerFunction, with one pass per API operation so that the errors are
+// recorded with their operation. The provisioned concurrency pass uses its own jobs. Unset concurrency settings are left as "-"
+func (app *application) getAllLambdaFunctionsConcurrency(ctx context.Context, lambdaFunctionsList []lambdaFunction, jobs <-chan job, maxWorkers int) {
+	app.logger.Info("getting concurrency settings for all lambda functions")
+	app.runPerFunction(ctx, lambdaFunctionsList, jobs, maxWorkers, "GetFunctionConcurrency", app.getLambdaFunctionReservedConcurrency, nil)
+
+	provisionedJobs := app.generateJobs(ctx, lambdaFunctionsList)
+	app.runPerFunction(ctx, lambdaFunctionsList, provisionedJobs, maxWorkers, "ListProvisionedConcurrencyConfigs", app.getLambdaFunctionProvisionedConcurrency, nil)
+	app.logger.Info("got concurrency settings for all lambda functions")
+}
 
-			lambdaFunctionsList[currentJob.index].LastInvoked = "-"
+// getLambdaFunctionReservedConcurrency gets the reserved concurrency of the Lambda function of the job
+func (app *application) getLambdaFunctionReservedConcurrency(ctx context.Context, currentJob job) (func(*lambdaFunction), error) {
+	out, err := app.getLambdaClient(currentJob.region).GetFunctionConcurrency(ctx, &lambda.GetFunctionConcurrencyInput{
+		FunctionName: aws.String(currentJob.functionArn),
+	})
+	if err != nil || out.ReservedConcurrentExecutions == nil {
+		return nil, err
+	}
+
+	return func(lambdaDetails *lambdaFunction) {
+		lambdaDetails.ReservedConcurrency = fmt.Sprint(*out.ReservedConcurrentExecutions)
+	}, nil
+}
+
+// getLambdaFunctionProvisionedConcurrency gets the total provisioned concurrency of all versions and aliases
+// of the Lambda function of the job
+func (app *application) getLambdaFunctionProvisionedConcurrency(ctx context.Context, currentJob job) (func(*lambdaFunction), error) {
+	provisionedConcurrency, configCount, err := app.getProvisionedConcurrency(ctx, app.getLambdaClient(currentJob.region), currentJob.functionArn)
+	if err != nil || configCount == 0 {
+		return nil, err
+	}
+
+	return func(lambdaDetails *lambdaFunction) {
+		lambdaDetails.ProvisionedConcurrency = fmt.Sprint(provisionedConcurrency)
+	}, nil
+}
+
+// getProvisionedConcurrency returns the sum of the allocated provisioned concurrency of all versions and aliases
+// of the function, together with the number of provisioned concurrency configs
+func (app *application) getProvisionedConcurrency(ctx context.Context, lambdaClient provisionedConcurrencyLister, functionArn string) (int32, int, error) {
+	var total int32
+	var configCount int
+
+	in := &lambda.ListProvisionedConcurrencyConfigsInput{
+		FunctionName: aws.String(functionArn),
+	}
+
+	for {
+		out, err := lambdaClient.ListProvisionedConcurrencyConfigs(ctx, in)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		for _, config := range out.ProvisionedConcurrencyConfigs {
+			total += aws.ToInt32(config.AllocatedProvisionedConcurrentExecutions)
+			configCount++
+		}
+
+		if out.NextMarker != nil {
+			in.Marker = out.NextMarker
+			continue
 		} else {
-			if out != nil && out.LogStreams != nil && out.LogStreams[0].LastEventTimestamp != nil {
-				lastEventTimestampInSeconds := *out.LogStreams[0].LastEventTimestamp / 1000
-				t := time.Unix(lastEventTimestampInSeconds, 0)
-
-				lambdaFunctionsList[currentJob.index].LastInvoked = t.Format("2006-01-02T15:04:05-07:00")
-				app.logger.Debugw("last invoke time info",
-					zap.Int64("*out.LogStreams[0].LastEventTimestamp", *out.LogStreams[0].LastEventTimestamp/1000),
-					zap.Int64("lastEventTimestampInSeconds", lastEventTimestampInSeconds),
-					zap.String("formatted time", t.Format("2006-01-02T15:04:05-07:00")),
-					zap.String("lambdaFunctionsList[index].lastInvoked", lambdaFunctionsList[currentJob.index].LastInvoked),
-				)
-			}
+			break
 		}
 	}
+
+	return total, configCount, nil
+}
+
+// getRegions returns the chosen regions
+func (app *application) getRegions() []string {
+	return app.regions
+}
+
+// getLambdaClient returns the Lambda client of the region
+func (app *application) getLambdaClient(region string) lambdaAPI {
+	return app.lambdaClients[region]
 }
@@ -0,0 +1,767 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	cwtypes "github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+	"go.uber.org/zap"
+)
+
+// fakeLambdaClient returns the ListFunctions pages keyed by the marker of the request, or err if it is set.
+// The other operations of lambdaAPI are not implemented and panic if they are called
+type fakeLambdaClient struct {
+	lambdaAPI
+
+	pages map[string]*lambda.ListFunctionsOutput
+	err   error
+
+	// inputs are copies of the ListFunctions requests, since the same input is reused for all pages
+	mu     sync.Mutex
+	inputs []lambda.ListFunctionsInput
+}
+
+func (f *fakeLambdaClient) ListFunctions(ctx context.Context, params *lambda.ListFunctionsInput, optFns ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.inputs = append(f.inputs, *params)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return f.pages[aws.ToString(params.Marker)], nil
+}
+
+// fakeCWLogsClient returns the log streams keyed by the log group name, or the error of the log group if it is set.
+// The other operations of cwLogsAPI are not implemented and panic if they are called
+type fakeCWLogsClient struct {
+	cwLogsAPI
+
+	logStreams map[string][]types.LogStream
+	errs       map[string]error
+
+	mu     sync.Mutex
+	inputs []*cloudwatchlogs.DescribeLogStreamsInput
+}
+
+func (f *fakeCWLogsClient) DescribeLogStreams(ctx context.Context, params *cloudwatchlogs.DescribeLogStreamsInput, optFns ...func(*cloudwatchlogs.Options)) (*cloudwatchlogs.DescribeLogStreamsOutput, error) {
+	f.mu.Lock()
+	f.inputs = append(f.inputs, params)
+	f.mu.Unlock()
+
+	logGroupName := aws.ToString(params.LogGroupName)
+	if err := f.errs[logGroupName]; err != nil {
+		return nil, err
+	}
+
+	return &cloudwatchlogs.DescribeLogStreamsOutput{LogStreams: f.logStreams[logGroupName]}, nil
+}
+
+// fakeCallerIdentityGetter returns account as the account ID, or err if it is set
+type fakeCallerIdentityGetter struct {
+	account string
+	err     error
+}
+
+func (f fakeCallerIdentityGetter) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &sts.GetCallerIdentityOutput{Account: aws.String(f.account)}, nil
+}
+
+// newTestApplication returns an application with the default settings of the flags used by the tested functions
+// and without any AWS client
+func newTestApplication() *application {
+	return &application{
+		logger:    zap.NewNop().Sugar(),
+		accountId: "-",
+		stg: settings{
+			listPageSize:    maxListPageSize,
+			fatalErrorCodes: defaultFatalErrorCodes,
+			utc:             true,
+		},
+	}
+}
+
+// functionConfigurations returns count function configurations named prefix-0, prefix-1, and so on
+func functionConfigurations(prefix string, count int) []lambdatypes.FunctionConfiguration {
+	functions := []lambdatypes.FunctionConfiguration{}
+	for i := range count {
+		name := fmt.Sprintf("%s-%d", prefix, i)
+		functions = append(functions, lambdatypes.FunctionConfiguration{
+			FunctionName: aws.String(name),
+			FunctionArn:  aws.String("arn:aws:lambda:us-east-1:123456789012:function:" + name),
+		})
+	}
+
+	return functions
+}
+
+func TestGetLambdaFunctionsDetailsPagination(t *testing.T) {
+	client := &fakeLambdaClient{
+		pages: map[string]*lambda.ListFunctionsOutput{
+			"":         {Functions: functionConfigurations("first", 2), NextMarker: aws.String("marker-1")},
+			"marker-1": {Functions: functionConfigurations("second", 1), NextMarker: aws.String("marker-2")},
+			"marker-2": {Functions: []lambdatypes.FunctionConfiguration{{}}},
+		},
+	}
+
+	app := newTestApplication()
+	app.accountId = "123456789012"
+
+	lambdaFunctionsList, err := app.getLambdaFunctionsDetails(context.Background(), "us-east-1", client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	markers := []string{}
+	for _, input := range client.inputs {
+		markers = append(markers, aws.ToString(input.Marker))
+	}
+	wantMarkers := []string{"", "marker-1", "marker-2"}
+	if !slices.Equal(markers, wantMarkers) {
+		t.Errorf("markers = %q, want %q", markers, wantMarkers)
+	}
+
+	names := []string{}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		names = append(names, lambdaDetails.Name)
+		if lambdaDetails.Region != "us-east-1" || lambdaDetails.AccountId != "123456789012" {
+			t.Errorf("function %q has region %q and account ID %q", lambdaDetails.Name, lambdaDetails.Region, lambdaDetails.AccountId)
+		}
+	}
+	wantNames := []string{"first-0", "first-1", "second-0", "-"}
+	if !slices.Equal(names, wantNames) {
+		t.Errorf("names = %q, want %q", names, wantNames)
+	}
+
+	// the function without any field is written with the defaults instead of failing
+	empty := lambdaFunctionsList[3]
+	if empty.Arn != "-" || empty.Description != "" || empty.LastInvoked != "-" {
+		t.Errorf("function without fields = %+v", empty)
+	}
+}
+
+func TestGetLambdaFunctionsDetailsError(t *testing.T) {
+	client := &fakeLambdaClient{err: errors.New("connection reset")}
+
+	_, err := newTestApplication().getLambdaFunctionsDetails(context.Background(), "us-east-1", client)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestGetAllLambdaFunctionsDetails(t *testing.T) {
+	accessDenied := &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not authorized"}
+	optInRequired := &smithy.GenericAPIError{Code: optInRequiredErrorCode, Message: "the region is not enabled"}
+	expiredToken := &smithy.GenericAPIError{Code: "ExpiredTokenException", Message: "the security token has expired"}
+
+	tests := []struct {
+		name               string
+		regionErrors       map[string]error
+		failOnAccessDenied bool
+		wantNames          []string
+		wantErr            bool
+		wantRunErrors      int
+	}{
+		{
+			name:      "all regions listed",
+			wantNames: []string{"ap-0", "eu-0", "us-0"},
+		},
+		{
+			name:          "access denied region is skipped",
+			regionErrors:  map[string]error{"eu-west-1": accessDenied},
+			wantNames:     []string{"ap-0", "us-0"},
+			wantRunErrors: 1,
+		},
+		{
+			name:               "access denied fails with -fail-on-access-denied",
+			regionErrors:       map[string]error{"eu-west-1": accessDenied},
+			failOnAccessDenied: true,
+			wantNames:          []string{"ap-0", "us-0"},
+			wantErr:            true,
+			wantRunErrors:      1,
+		},
+		{
+			name:          "unavailable region is skipped",
+			regionErrors:  map[string]error{"ap-southeast-1": optInRequired},
+			wantNames:     []string{"eu-0", "us-0"},
+			wantRunErrors: 1,
+		},
+		{
+			name:          "endpoint not found region is skipped",
+			regionErrors:  map[string]error{"ap-southeast-1": &aws.EndpointNotFoundError{Err: errors.New("no endpoint")}},
+			wantNames:     []string{"eu-0", "us-0"},
+			wantRunErrors: 1,
+		},
+		{
+			name:          "unresolved endpoint region is skipped",
+			regionErrors:  map[string]error{"ap-southeast-1": errors.New("unable to resolve endpoint for region ap-southeast-1")},
+			wantNames:     []string{"eu-0", "us-0"},
+			wantRunErrors: 1,
+		},
+		{
+			name:          "fatal error is returned",
+			regionErrors:  map[string]error{"us-east-1": expiredToken},
+			wantNames:     []string{"ap-0", "eu-0"},
+			wantErr:       true,
+			wantRunErrors: 1,
+		},
+		{
+			name:          "other error is returned",
+			regionErrors:  map[string]error{"us-east-1": errors.New("connection reset")},
+			wantNames:     []string{"ap-0", "eu-0"},
+			wantErr:       true,
+			wantRunErrors: 1,
+		},
+	}
+
+	regionPrefixes := map[string]string{"us-east-1": "us", "eu-west-1": "eu", "ap-southeast-1": "ap"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication()
+			app.stg.failOnAccessDenied = tt.failOnAccessDenied
+			app.lambdaClients = map[string]lambdaAPI{}
+			for region, prefix := range regionPrefixes {
+				app.regions = append(app.regions, region)
+				app.lambdaClients[region] = &fakeLambdaClient{
+					pages: map[string]*lambda.ListFunctionsOutput{"": {Functions: functionConfigurations(prefix, 1)}},
+					err:   tt.regionErrors[region],
+				}
+			}
+
+			lambdaFunctionsList, err := app.getAllLambdaFunctionsDetails(context.Background(), 2)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			names := []string{}
+			for _, lambdaDetails := range lambdaFunctionsList {
+				names = append(names, lambdaDetails.Name)
+			}
+			if !slices.Equal(names, tt.wantNames) {
+				t.Errorf("names = %q, want %q", names, tt.wantNames)
+			}
+
+			if got := len(app.getErrors()); got != tt.wantRunErrors {
+				t.Errorf("recorded %d errors, want %d", got, tt.wantRunErrors)
+			}
+		})
+	}
+}
+
+func TestGetLambdaFunctionLastInvokeTime(t *testing.T) {
+	logGroupMissing := &smithy.OperationError{
+		ServiceID:     "CloudWatch Logs",
+		OperationName: "DescribeLogStreams",
+		Err:           &types.ResourceNotFoundException{Message: aws.String("The specified log group does not exist.")},
+	}
+	lastEvent := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		logStreams      []types.LogStream
+		err             error
+		wantLastInvoked string
+		wantErr         bool
+	}{
+		{
+			name:            "latest log stream",
+			logStreams:      []types.LogStream{{LastEventTimestamp: aws.Int64(lastEvent.UnixMilli())}},
+			wantLastInvoked: "2024-05-01T12:30:00+00:00",
+		},
+		{
+			name:            "log group missing",
+			err:             logGroupMissing,
+			wantLastInvoked: "-",
+		},
+		{
+			name:            "no log stream",
+			wantLastInvoked: "-",
+		},
+		{
+			name:            "log stream without event",
+			logStreams:      []types.LogStream{{LogStreamName: aws.String("empty")}},
+			wantLastInvoked: "-",
+		},
+		{
+			name:            "other error",
+			err:             errors.New("connection reset"),
+			wantLastInvoked: "-",
+			wantErr:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logGroupName := lambdaLogGroupPrefix + "my-function"
+			app := newTestApplication()
+			app.cwLogsClients = map[string]cwLogsAPI{
+				"us-east-1": &fakeCWLogsClient{
+					logStreams: map[string][]types.LogStream{logGroupName: tt.logStreams},
+					errs:       map[string]error{logGroupName: tt.err},
+				},
+			}
+
+			result := app.getLambdaFunctionLastInvokeTime(context.Background(), job{functionName: "my-function", region: "us-east-1", index: 3})
+			if result.lastInvoked != tt.wantLastInvoked {
+				t.Errorf("lastInvoked = %q, want %q", result.lastInvoked, tt.wantLastInvoked)
+			}
+			if (result.err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", result.err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetAllLambdaFunctionsLastInvokeTime(t *testing.T) {
+	lastEvent := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	expiredToken := &smithy.GenericAPIError{Code: "ExpiredTokenException", Message: "the security token has expired"}
+
+	tests := []struct {
+		name            string
+		err             error
+		wantLastInvoked string
+		wantErrors      bool
+	}{
+		{
+			name:            "all functions resolved",
+			wantLastInvoked: "2024-05-01T12:30:00+00:00",
+		},
+		{
+			name:            "fatal error stops the remaining functions",
+			err:             expiredToken,
+			wantLastInvoked: "-",
+			wantErrors:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeCWLogsClient{
+				logStreams: map[string][]types.LogStream{},
+				errs:       map[string]error{},
+			}
+
+			lambdaFunctionsList := []lambdaFunction{}
+			for _, functionDetail := range functionConfigurations("function", 50) {
+				lambdaDetails := newLambdaFunction(functionDetail, "us-east-1")
+				lambdaFunctionsList = append(lambdaFunctionsList, lambdaDetails)
+
+				logGroupName := lambdaLogGroupPrefix + lambdaDetails.Name
+				client.logStreams[logGroupName] = []types.LogStream{{LastEventTimestamp: aws.Int64(lastEvent.UnixMilli())}}
+				client.errs[logGroupName] = tt.err
+			}
+
+			app := newTestApplication()
+			app.cwLogsClients = map[string]cwLogsAPI{"us-east-1": client}
+
+			resolved := make(chan int)
+			app.lastInvokeResults = resolved
+			resolvedIndexes := []int{}
+			collected := make(chan struct{})
+			go func() {
+				for index := range resolved {
+					resolvedIndexes = append(resolvedIndexes, index)
+				}
+				close(collected)
+			}()
+
+			ctx := context.Background()
+			app.getAllLambdaFunctionsLastInvokeTime(ctx, lambdaFunctionsList, app.generateJobs(ctx, lambdaFunctionsList), 5)
+			close(resolved)
+			<-collected
+
+			// every function is reported as resolved, also the ones skipped after the fatal error
+			slices.Sort(resolvedIndexes)
+			for i, index := range resolvedIndexes {
+				if i != index {
+					t.Fatalf("resolved indexes = %v, want every index from 0 to %d once", resolvedIndexes, len(lambdaFunctionsList)-1)
+				}
+			}
+			if len(resolvedIndexes) != len(lambdaFunctionsList) {
+				t.Fatalf("resolved %d functions, want %d", len(resolvedIndexes), len(lambdaFunctionsList))
+			}
+
+			for _, lambdaDetails := range lambdaFunctionsList {
+				if lambdaDetails.LastInvoked != tt.wantLastInvoked {
+					t.Errorf("last invoke time of %q = %q, want %q", lambdaDetails.Name, lambdaDetails.LastInvoked, tt.wantLastInvoked)
+				}
+			}
+
+			runErrors := app.getErrors()
+			if tt.wantErrors {
+				// the fatal error stops sending the other functions, so only a few of them are called
+				if len(runErrors) == 0 || len(client.inputs) == len(lambdaFunctionsList) {
+					t.Errorf("recorded %d errors after %d calls, want the calls to stop after the fatal error", len(runErrors), len(client.inputs))
+				}
+			} else if len(runErrors) != 0 {
+				t.Errorf("unexpected errors: %v", runErrors)
+			}
+		})
+	}
+}
+
+// fakeCWClient returns the Invocations datapoints keyed by function name, or the error of the function if it is set
+type fakeCWClient struct {
+	datapoints map[string][]float64
+	errs       map[string]error
+}
+
+func (f *fakeCWClient) GetMetricData(ctx context.Context, params *cloudwatch.GetMetricDataInput, optFns ...func(*cloudwatch.Options)) (*cloudwatch.GetMetricDataOutput, error) {
+	functionName := aws.ToString(params.MetricDataQueries[0].MetricStat.Metric.Dimensions[0].Value)
+	if err := f.errs[functionName]; err != nil {
+		return nil, err
+	}
+
+	result := cwtypes.MetricDataResult{Id: aws.String(invocationsMetricQueryID)}
+	for i, value := range f.datapoints[functionName] {
+		result.Values = append(result.Values, value)
+		result.Timestamps = append(result.Timestamps, time.Date(2024, 5, 1, 12-i, 0, 0, 0, time.UTC))
+	}
+
+	return &cloudwatch.GetMetricDataOutput{MetricDataResults: []cwtypes.MetricDataResult{result}}, nil
+}
+
+func TestGetAllLambdaFunctionsLastInvokeTimeFromMetrics(t *testing.T) {
+	client := &fakeCWClient{
+		datapoints: map[string][]float64{"busy": {0, 3, 2}},
+		errs:       map[string]error{"broken": errors.New("connection reset by peer")},
+	}
+
+	lambdaFunctionsList := []lambdaFunction{}
+	for _, name := range []string{"busy", "idle", "broken"} {
+		lambdaFunctionsList = append(lambdaFunctionsList, newLambdaFunction(lambdatypes.FunctionConfiguration{FunctionName: aws.String(name)}, "us-east-1"))
+	}
+
+	app := newTestApplication()
+	app.stg.lastInvokeSource = lastInvokeSourceMetrics
+	app.cwClients = map[string]metricDataGetter{"us-east-1": client}
+
+	ctx := context.Background()
+	app.getAllLambdaFunctionsLastInvokeTime(ctx, lambdaFunctionsList, app.generateJobs(ctx, lambdaFunctionsList), 2)
+
+	// the function whose metric couldn't be read keeps "-" instead of looking like it was never invoked
+	want := map[string][2]string{
+		"busy":   {"2024-05-01T11:00:00+00:00", "5"},
+		"idle":   {"-", "0"},
+		"broken": {"-", "-"},
+	}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		got := [2]string{lambdaDetails.LastInvoked, lambdaDetails.InvokeCount}
+		if got != want[lambdaDetails.Name] {
+			t.Errorf("last invoke time and count of %q = %q, want %q", lambdaDetails.Name, got, want[lambdaDetails.Name])
+		}
+	}
+
+	if runErrors := app.getErrors(); len(runErrors) != 1 {
+		t.Errorf("recorded %d errors, want 1: %+v", len(runErrors), runErrors)
+	}
+}
+
+func TestGetAccountId(t *testing.T) {
+	accountId, err := getAccountId(context.Background(), fakeCallerIdentityGetter{account: "123456789012"})
+	if err != nil || accountId != "123456789012" {
+		t.Errorf("getAccountId() = %q, %v, want 123456789012", accountId, err)
+	}
+
+	_, err = getAccountId(context.Background(), fakeCallerIdentityGetter{err: errors.New("access denied")})
+	if err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestGetLambdaFunctionLastInvokeTimeNewestStream(t *testing.T) {
+	logGroupName := lambdaLogGroupPrefix + "my-function"
+	oldest := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newest := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	client := &fakeCWLogsClient{
+		logStreams: map[string][]types.LogStream{
+			logGroupName: {
+				{LogStreamName: aws.String("oldest"), LastEventTimestamp: aws.Int64(oldest.UnixMilli())},
+				{LogStreamName: aws.String("empty")},
+				{LogStreamName: aws.String("newest"), LastEventTimestamp: aws.Int64(newest.UnixMilli())},
+				{LogStreamName: aws.String("middle"), LastEventTimestamp: aws.Int64(newest.AddDate(0, -1, 0).UnixMilli())},
+			},
+		},
+	}
+
+	app := newTestApplication()
+	app.cwLogsClients = map[string]cwLogsAPI{"us-east-1": client}
+
+	result := app.getLambdaFunctionLastInvokeTime(context.Background(), job{functionName: "my-function", region: "us-east-1"})
+	if want := "2024-05-01T12:30:00+00:00"; result.lastInvoked != want {
+		t.Errorf("lastInvoked = %q, want %q", result.lastInvoked, want)
+	}
+
+	// without Descending, the first stream ordered by LastEventTime would be the oldest one
+	input := client.inputs[0]
+	if !aws.ToBool(input.Descending) || input.OrderBy != types.OrderByLastEventTime {
+		t.Errorf("DescribeLogStreams is called with Descending %v and OrderBy %q, want the newest stream first",
+			aws.ToBool(input.Descending), input.OrderBy)
+	}
+}
+
+func TestGetLatestEventTimestamp(t *testing.T) {
+	tests := []struct {
+		name       string
+		logStreams []types.LogStream
+		want       int64
+		wantFound  bool
+	}{
+		{
+			name: "newest of several streams",
+			logStreams: []types.LogStream{
+				{LastEventTimestamp: aws.Int64(100)},
+				{LastEventTimestamp: aws.Int64(300)},
+				{LastEventTimestamp: aws.Int64(200)},
+			},
+			want:      300,
+			wantFound: true,
+		},
+		{
+			name: "streams without event are ignored",
+			logStreams: []types.LogStream{
+				{},
+				{LastEventTimestamp: aws.Int64(100)},
+			},
+			want:      100,
+			wantFound: true,
+		},
+		{
+			name:       "no stream with an event",
+			logStreams: []types.LogStream{{}},
+		},
+		{
+			name: "no stream",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := getLatestEventTimestamp(tt.logStreams)
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("getLatestEventTimestamp() = %d, %v, want %d, %v", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestGenerateJobsMoreFunctionsThanWorkers(t *testing.T) {
+	const functionCount = 200
+	const maxWorkers = 5
+
+	client := &fakeCWLogsClient{}
+	lambdaFunctionsList := []lambdaFunction{}
+	for _, functionDetail := range functionConfigurations("function", functionCount) {
+		lambdaFunctionsList = append(lambdaFunctionsList, newLambdaFunction(functionDetail, "us-east-1"))
+	}
+
+	app := newTestApplication()
+	app.cwLogsClients = map[string]cwLogsAPI{"us-east-1": client}
+
+	// the jobs used to be sent before starting the workers, which blocked as soon as there were more functions than workers
+	done := make(chan struct{})
+	go func() {
+		ctx := context.Background()
+		app.getAllLambdaFunctionsLastInvokeTime(ctx, lambdaFunctionsList, app.generateJobs(ctx, lambdaFunctionsList), maxWorkers)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("getting the last invoke time of %d functions with %d workers didn't complete", functionCount, maxWorkers)
+	}
+
+	if len(client.inputs) != functionCount {
+		t.Errorf("DescribeLogStreams is called %d times, want %d", len(client.inputs), functionCount)
+	}
+}
+
+func TestGenerateJobsCancelled(t *testing.T) {
+	lambdaFunctionsList := []lambdaFunction{}
+	for _, functionDetail := range functionConfigurations("function", 200) {
+		lambdaFunctionsList = append(lambdaFunctionsList, newLambdaFunction(functionDetail, "us-east-1"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	jobs := newTestApplication().generateJobs(ctx, lambdaFunctionsList)
+
+	first := <-jobs
+	if first.index != 0 || first.functionName != "function-0" || first.region != "us-east-1" {
+		t.Errorf("first job = %+v", first)
+	}
+
+	// the channel is closed after the cancellation, so that the workers don't wait for the remaining jobs
+	cancel()
+	received := 1
+	for range jobs {
+		received++
+	}
+	if received == len(lambdaFunctionsList) {
+		t.Errorf("received all %d jobs after the cancellation", received)
+	}
+}
+
+// BenchmarkCWLogsClientPerJob measures creating a CloudWatch Logs client for every function,
+// which is what the last invoke workers used to do
+func BenchmarkCWLogsClientPerJob(b *testing.B) {
+	cfg := aws.Config{Region: "us-east-1"}
+	b.ReportAllocs()
+
+	for b.Loop() {
+		var client cwLogsAPI = cloudwatchlogs.NewFromConfig(cfg, func(o *cloudwatchlogs.Options) {
+			o.Region = "eu-west-1"
+		})
+		_ = client
+	}
+}
+
+// BenchmarkCWLogsClientPerRegion measures looking up the CloudWatch Logs client of the region,
+// which is created once by initializeApplication and reused for all functions
+func BenchmarkCWLogsClientPerRegion(b *testing.B) {
+	cfg := aws.Config{Region: "us-east-1"}
+	app := newTestApplication()
+	app.cwLogsClients = map[string]cwLogsAPI{
+		"eu-west-1": cloudwatchlogs.NewFromConfig(cfg, func(o *cloudwatchlogs.Options) {
+			o.Region = "eu-west-1"
+		}),
+	}
+	b.ReportAllocs()
+
+	for b.Loop() {
+		client := app.cwLogsClients["eu-west-1"]
+		_ = client
+	}
+}
+
+// pagedLambdaClient returns functions in pages of MaxItems functions, with the index of the next function as the marker
+type pagedLambdaClient struct {
+	lambdaAPI
+
+	functions []lambdatypes.FunctionConfiguration
+	requests  int
+}
+
+func (p *pagedLambdaClient) ListFunctions(ctx context.Context, params *lambda.ListFunctionsInput, optFns ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
+	p.requests++
+
+	start := 0
+	if params.Marker != nil {
+		start, _ = strconv.Atoi(*params.Marker)
+	}
+	end := min(start+int(aws.ToInt32(params.MaxItems)), len(p.functions))
+
+	out := &lambda.ListFunctionsOutput{Functions: p.functions[start:end]}
+	if end < len(p.functions) {
+		out.NextMarker = aws.String(strconv.Itoa(end))
+	}
+
+	return out, nil
+}
+
+// BenchmarkListFunctionsPageSize measures the ListFunctions requests needed to list 10000 functions with each page size
+func BenchmarkListFunctionsPageSize(b *testing.B) {
+	functions := functionConfigurations("function", 10000)
+
+	for _, listPageSize := range []int{50, 1000, maxListPageSize} {
+		b.Run(fmt.Sprintf("page_size_%d", listPageSize), func(b *testing.B) {
+			app := newTestApplication()
+			app.stg.listPageSize = listPageSize
+			client := &pagedLambdaClient{functions: functions}
+			b.ReportAllocs()
+
+			for b.Loop() {
+				lambdaFunctionsList, err := app.getLambdaFunctionsDetails(context.Background(), "us-east-1", client)
+				if err != nil || len(lambdaFunctionsList) != len(functions) {
+					b.Fatalf("listed %d functions with error %v", len(lambdaFunctionsList), err)
+				}
+			}
+
+			b.ReportMetric(float64(client.requests)/float64(b.N), "requests/op")
+		})
+	}
+}
+
+func TestValidateListPageSize(t *testing.T) {
+	for _, listPageSize := range []int{1, 50, maxListPageSize} {
+		if err := validateListPageSize(listPageSize); err != nil {
+			t.Errorf("validateListPageSize(%d) = %v, want nil", listPageSize, err)
+		}
+	}
+
+	for _, listPageSize := range []int{-1, 0, maxListPageSize + 1} {
+		if err := validateListPageSize(listPageSize); err == nil {
+			t.Errorf("validateListPageSize(%d) = nil, want an error", listPageSize)
+		}
+	}
+}
+
+func TestRunPerFunctionWritesEveryIndexOnce(t *testing.T) {
+	lambdaFunctionsList := []lambdaFunction{}
+	for _, functionDetail := range functionConfigurations("function", 100) {
+		lambdaFunctionsList = append(lambdaFunctionsList, newLambdaFunction(functionDetail, "us-east-1"))
+	}
+
+	app := newTestApplication()
+	resolvedCounts := make([]int, len(lambdaFunctionsList))
+	ctx := context.Background()
+
+	app.runPerFunction(ctx, lambdaFunctionsList, app.generateJobs(ctx, lambdaFunctionsList), 8, "GetFunction",
+		func(ctx context.Context, currentJob job) (func(*lambdaFunction), error) {
+			if currentJob.index%10 == 0 {
+				return nil, errors.New("connection reset")
+			}
+
+			return func(lambdaDetails *lambdaFunction) {
+				lambdaDetails.CodeSize++
+				lambdaDetails.Handler = currentJob.functionName
+			}, nil
+		},
+		func(index int) {
+			resolvedCounts[index]++
+		},
+	)
+
+	for i, lambdaDetails := range lambdaFunctionsList {
+		if resolvedCounts[i] != 1 {
+			t.Errorf("function %d is resolved %d times, want once", i, resolvedCounts[i])
+		}
+
+		wantCodeSize := int64(1)
+		if i%10 == 0 {
+			wantCodeSize = 0
+		}
+		if lambdaDetails.CodeSize != wantCodeSize {
+			t.Errorf("function %d is written %d times, want %d", i, lambdaDetails.CodeSize, wantCodeSize)
+		}
+
+		// the result of each job is written in the function of the same index
+		if wantCodeSize == 1 && lambdaDetails.Handler != lambdaDetails.Name {
+			t.Errorf("function %q has the result of %q", lambdaDetails.Name, lambdaDetails.Handler)
+		}
+	}
+
+	if runErrors := app.getErrors(); len(runErrors) != 10 {
+		t.Errorf("recorded %d errors, want 10", len(runErrors))
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadConfigFile sets the flags which are not passed on the command line using the values in the YAML config file.
+// The keys of the file are the flag names without the dash, e.g. aws-profile or max-workers.
+// A list value is joined with commas, except for the flags that can be passed multiple times, e.g. runtime
+func loadConfigFile(fs *flag.FlagSet, fileName string) error {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("error when reading the config file: %w", err)
+	}
+
+	values := map[string]any{}
+	err = yaml.Unmarshal(content, &values)
+	if err != nil {
+		return fmt.Errorf("error when parsing the config file: %w", err)
+	}
+
+	// the flags passed on the command line override the values in the config file
+	passedFlags := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		passedFlags[f.Name] = true
+	})
+
+	for name, value := range values {
+		f := fs.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("unknown setting %q in the config file", name)
+		}
+		if passedFlags[name] {
+			continue
+		}
+
+		for _, v := range configValueToFlagValues(f, value) {
+			err = fs.Set(name, v)
+			if err != nil {
+				return fmt.Errorf("invalid value for setting %q in the config file: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// configValueToFlagValues converts the config file value to the strings passed to flag.Set
+func configValueToFlagValues(f *flag.Flag, value any) []string {
+	list, ok := value.([]any)
+	if !ok {
+		return []string{fmt.Sprint(value)}
+	}
+
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		items = append(items, fmt.Sprint(item))
+	}
+
+	// the value of a flag that can be passed multiple times is set once per item
+	if _, ok := f.Value.(*stringListFlag); ok {
+		return items
+	}
+
+	return []string{strings.Join(items, ",")}
+}
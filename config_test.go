@@ -0,0 +1,118 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// newTestFlagSet returns a flag set with a few flags of each kind, like the flags defined in main
+func newTestFlagSet(profile *string, maxWorkers *int, regions *string, runtimes *stringListFlag, includeVersions *bool) *flag.FlagSet {
+	fs := flag.NewFlagSet("alli-lister", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(profile, "aws-profile", "", "")
+	fs.IntVar(maxWorkers, "max-workers", 10, "")
+	fs.StringVar(regions, "regions", "", "")
+	fs.Var(runtimes, "runtime", "")
+	fs.BoolVar(includeVersions, "include-versions", false, "")
+
+	return fs
+}
+
+// writeTestConfigFile writes content to a config file in a temporary directory and returns its name
+func writeTestConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	fileName := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(fileName, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fileName
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	fileName := writeTestConfigFile(t, `
+aws-profile: from-config
+max-workers: 20
+regions:
+  - us-east-1
+  - eu-west-1
+runtime:
+  - python3.12
+  - nodejs20.x
+include-versions: true
+`)
+
+	var profile, regions string
+	var maxWorkers int
+	var runtimes stringListFlag
+	var includeVersions bool
+	fs := newTestFlagSet(&profile, &maxWorkers, &regions, &runtimes, &includeVersions)
+
+	// the flags passed on the command line override the config file
+	err := fs.Parse([]string{"-aws-profile", "from-flag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = loadConfigFile(fs, fileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if profile != "from-flag" {
+		t.Errorf("aws-profile = %q, want the command line value", profile)
+	}
+	if maxWorkers != 20 {
+		t.Errorf("max-workers = %d, want 20", maxWorkers)
+	}
+	if regions != "us-east-1,eu-west-1" {
+		t.Errorf("regions = %q, want the list joined with commas", regions)
+	}
+	if !slices.Equal(runtimes, stringListFlag{"python3.12", "nodejs20.x"}) {
+		t.Errorf("runtime = %q, want one value per item", runtimes)
+	}
+	if !includeVersions {
+		t.Error("include-versions = false, want true")
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "unknown setting", content: "unknown-flag: true\n"},
+		{name: "invalid value", content: "max-workers: many\n"},
+		{name: "invalid YAML", content: "max-workers: [\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var profile, regions string
+			var maxWorkers int
+			var runtimes stringListFlag
+			var includeVersions bool
+			fs := newTestFlagSet(&profile, &maxWorkers, &regions, &runtimes, &includeVersions)
+
+			err := loadConfigFile(fs, writeTestConfigFile(t, tt.content))
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	var profile, regions string
+	var maxWorkers int
+	var runtimes stringListFlag
+	var includeVersions bool
+	fs := newTestFlagSet(&profile, &maxWorkers, &regions, &runtimes, &includeVersions)
+	if err := loadConfigFile(fs, filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing config file")
+	}
+}
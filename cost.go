@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+const (
+	durationMetricName    = "Duration"
+	durationMetricQueryID = "duration"
+
+	// costEstimateDaysPerMonth is the number of days of the estimated monthly cost
+	costEstimateDaysPerMonth = 30
+)
+
+// lambdaPrice is the on-demand price of Lambda in one region, without the free tier
+type lambdaPrice struct {
+	// perMillionRequests is the price of one million invocations
+	perMillionRequests float64
+
+	// perGBSecondX86 and perGBSecondArm are the price of one GB-second of duration by architecture
+	perGBSecondX86 float64
+	perGBSecondArm float64
+}
+
+// defaultLambdaPrice is the price of the regions that are not in lambdaPricesByRegion,
+// which is the price of us-east-1 and most other regions
+var defaultLambdaPrice = lambdaPrice{
+	perMillionRequests: 0.20,
+	perGBSecondX86:     0.0000166667,
+	perGBSecondArm:     0.0000133334,
+}
+
+// lambdaPricesByRegion are the prices of the regions which differ from defaultLambdaPrice.
+// See https://aws.amazon.com/lambda/pricing/ to update them
+var lambdaPricesByRegion = map[string]lambdaPrice{
+	"af-south-1": {
+		perMillionRequests: 0.28,
+		perGBSecondX86:     0.0000221,
+		perGBSecondArm:     0.0000177,
+	},
+}
+
+// getLambdaPrice returns the Lambda price of the region
+func getLambdaPrice(region string) lambdaPrice {
+	if price, ok := lambdaPricesByRegion[region]; ok {
+		return price
+	}
+
+	return defaultLambdaPrice
+}
+
+// estimateMonthlyCost estimates the monthly cost in USD of a function from the number of invocations
+// and the total duration in milliseconds over lookbackDays, scaled to costEstimateDaysPerMonth days
+func estimateMonthlyCost(price lambdaPrice, invocations float64, durationMs float64, memorySizeMB int32, architectures string, lookbackDays int) float64 {
+	perGBSecond := price.perGBSecondX86
+	if architectures == string(lambdatypes.ArchitectureArm64) {
+		perGBSecond = price.perGBSecondArm
+	}
+
+	gbSeconds := durationMs / 1000 * float64(memorySizeMB) / 1024
+	cost := gbSeconds*perGBSecond + invocations/1_000_000*price.perMillionRequests
+
+	return cost * costEstimateDaysPerMonth / float64(lookbackDays)
+}
+
+// formatCost formats the estimated cost in USD, which is $0 for the functions without any invocation.
+// The costs under a cent are written as <$0.01, so that they're not mistaken for functions without any invocation
+func formatCost(cost float64) string {
+	if cost == 0 {
+		return "$0"
+	}
+	if cost < 0.01 {
+		return "<$0.01"
+	}
+
+	return fmt.Sprintf("$%.2f", cost)
+}
+
+// getAllLambdaFunctionsCostEstimate estimates the monthly cost of all Lambda functions concurrently using runPerFunction
+func (app *application) getAllLambdaFunctionsCostEstimate(ctx context.Context, lambdaFunctionsList []lambdaFunction, jobs <-chan job, maxWorkers int) {
+	app.logger.Info("estimating the monthly cost of all lambda functions")
+	app.runPerFunction(ctx, lambdaFunctionsList, jobs, maxWorkers, "GetMetricData", func(ctx context.Context, currentJob job) (func(*lambdaFunction), error) {
+		lambdaDetails := lambdaFunctionsList[currentJob.index]
+		// the metrics don't have the version dimension, so only the $LATEST rows get the estimate of the whole function
+		if lambdaDetails.Version != latestVersion {
+			return nil, nil
+		}
+
+		return app.getLambdaFunctionCostEstimate(ctx, currentJob, lambdaDetails.MemorySize, lambdaDetails.Architectures)
+	}, nil)
+	app.logger.Info("estimated the monthly cost of all lambda functions")
+}
+
+// getLambdaFunctionCostEstimate gets the total of the CloudWatch Invocations and Duration metrics over the
+// -metrics-lookback-days window of the Lambda function of the job, and estimates its monthly cost
+func (app *application) getLambdaFunctionCostEstimate(ctx context.Context, currentJob job, memorySizeMB int32, architectures string) (func(*lambdaFunction), error) {
+	endTime := time.Now()
+	startTime := endTime.AddDate(0, 0, -app.stg.metricsLookbackDays)
+
+	input := &cloudwatch.GetMetricDataInput{
+		StartTime: aws.Time(startTime),
+		EndTime:   aws.Time(endTime),
+		MetricDataQueries: []types.MetricDataQuery{
+			newFunctionMetricQuery(invocationsMetricQueryID, invocationsMetricName, currentJob.functionName),
+			newFunctionMetricQuery(durationMetricQueryID, durationMetricName, currentJob.functionName),
+		},
+	}
+
+	totals, err := getMetricTotals(ctx, app.cwClients[currentJob.region], input)
+	if err != nil {
+		return nil, err
+	}
+
+	price := getLambdaPrice(currentJob.region)
+	cost := estimateMonthlyCost(price, totals[invocationsMetricQueryID], totals[durationMetricQueryID], memorySizeMB, architectures, app.stg.metricsLookbackDays)
+
+	return func(lambdaDetails *lambdaFunction) {
+		lambdaDetails.EstMonthlyUSD = formatCost(cost)
+	}, nil
+}
+
+// newFunctionMetricQuery creates the query of the hourly sum of the AWS/Lambda metric of the function
+func newFunctionMetricQuery(id string, metricName string, functionName string) types.MetricDataQuery {
+	return types.MetricDataQuery{
+		Id: aws.String(id),
+		MetricStat: &types.MetricStat{
+			Metric: &types.Metric{
+				Namespace:  aws.String(lambdaMetricsNamespace),
+				MetricName: aws.String(metricName),
+				Dimensions: []types.Dimension{
+					{
+						Name:  aws.String(functionNameDimension),
+						Value: aws.String(functionName),
+					},
+				},
+			},
+			Period: aws.Int32(invocationsMetricPeriodInSeconds),
+			Stat:   aws.String(string(types.StatisticSum)),
+		},
+	}
+}
+
+// getMetricTotals pages through all metric datapoints and returns the sum of the datapoints keyed by query ID
+func getMetricTotals(ctx context.Context, cwClient metricDataGetter, input *cloudwatch.GetMetricDataInput) (map[string]float64, error) {
+	totals := map[string]float64{}
+	for {
+		out, err := cwClient.GetMetricData(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, result := range out.MetricDataResults {
+			for _, value := range result.Values {
+				totals[aws.ToString(result.Id)] += value
+			}
+		}
+
+		if out.NextToken == nil {
+			return totals, nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
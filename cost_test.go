@@ -0,0 +1,60 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateMonthlyCost(t *testing.T) {
+	// one million invocations of 100 ms each, which is 100,000 GB-seconds with 1024 MB of memory
+	const invocations = 1_000_000
+	const durationMs = 100 * invocations
+
+	tests := []struct {
+		name          string
+		region        string
+		memorySizeMB  int32
+		architectures string
+		lookbackDays  int
+		want          float64
+	}{
+		{name: "x86", region: "us-east-1", memorySizeMB: 1024, architectures: "x86_64", lookbackDays: 30, want: 1.86667},
+		{name: "arm", region: "us-east-1", memorySizeMB: 1024, architectures: "arm64", lookbackDays: 30, want: 1.53334},
+		{name: "half the memory", region: "us-east-1", memorySizeMB: 512, architectures: "x86_64", lookbackDays: 30, want: 1.033335},
+		{name: "scaled to a month", region: "us-east-1", memorySizeMB: 1024, architectures: "x86_64", lookbackDays: 7, want: 1.86667 * 30 / 7},
+		{name: "region with another price", region: "af-south-1", memorySizeMB: 1024, architectures: "x86_64", lookbackDays: 30, want: 2.49},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := estimateMonthlyCost(getLambdaPrice(tt.region), invocations, durationMs, tt.memorySizeMB, tt.architectures, tt.lookbackDays)
+			if math.Abs(got-tt.want) > 0.00001 {
+				t.Errorf("estimateMonthlyCost() = %f, want %f", got, tt.want)
+			}
+		})
+	}
+
+	if got := estimateMonthlyCost(defaultLambdaPrice, 0, 0, 1024, "x86_64", 30); got != 0 {
+		t.Errorf("estimateMonthlyCost() without invocations = %f, want 0", got)
+	}
+}
+
+func TestFormatCost(t *testing.T) {
+	tests := []struct {
+		cost float64
+		want string
+	}{
+		{cost: 0, want: "$0"},
+		{cost: 0.001, want: "<$0.01"},
+		{cost: 0.0099, want: "<$0.01"},
+		{cost: 0.01, want: "$0.01"},
+		{cost: 1.86667, want: "$1.87"},
+		{cost: 1234.5, want: "$1234.50"},
+	}
+
+	for _, tt := range tests {
+		if got := formatCost(tt.cost); got != tt.want {
+			t.Errorf("formatCost(%f) = %q, want %q", tt.cost, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	ssotypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"go.uber.org/zap"
+)
+
+const defaultRoleSessionName = "alli-lister"
+
+// loadAWSConfig loads the AWS config from the chosen AWS profile.
+// If the profile name is empty or useEnvCreds is set, the profile is not used and the credentials are taken
+// from the default credential chain instead, e.g. environment variables or the ECS/EC2 role.
+// If the profile assumes a role with MFA, the MFA token is taken from -mfa-token or prompted.
+// The -mfa-serial overrides the mfa_serial of the profile, unless -assume-role-arn is set, in which case it is
+// used when assuming that role instead.
+// The default region is resolved here once with getDefaultRegion, so that the functions file, the assumed role,
+// and all service clients use the same region
+func loadAWSConfig(ctx context.Context, logger *zap.SugaredLogger, stg settings) (aws.Config, error) {
+	optFns := []func(*config.LoadOptions) error{
+		config.WithRetryer(func() aws.Retryer {
+			return newRetryer(stg.maxRetries)
+		}),
+	}
+
+	if stg.awsProfileName == "" || stg.useEnvCreds {
+		logger.Debug("loading config from the default credential chain")
+	} else {
+		logger.Debugf("loading config from aws profile named %q", stg.awsProfileName)
+		optFns = append(optFns,
+			config.WithSharedConfigProfile(stg.awsProfileName),
+			config.WithAssumeRoleCredentialOptions(func(o *stscreds.AssumeRoleOptions) {
+				o.TokenProvider = newMFATokenProvider(stg.mfaToken)
+				if stg.mfaSerial != "" && stg.assumeRoleArn == "" {
+					o.SerialNumber = aws.String(stg.mfaSerial)
+				}
+			}),
+		)
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	// the account ID and the enabled regions are taken from the default region, so the clients can't be created without it
+	cfg.Region, err = getDefaultRegion(cfg.Region, parseCommaSeparatedList(stg.regions))
+	if err != nil {
+		return aws.Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// getDefaultRegion returns the region of the AWS config. When the profile has no region, the first region of -regions
+// is used instead, and otherwise it returns an error that tells how to set one
+func getDefaultRegion(cfgRegion string, chosenRegions []string) (string, error) {
+	if cfgRegion != "" {
+		return cfgRegion, nil
+	}
+
+	if len(chosenRegions) > 0 {
+		return chosenRegions[0], nil
+	}
+
+	return "", errors.New("no AWS region is set, set the region of the profile or the AWS_REGION environment variable, " +
+		"or choose the regions with -regions, since -all-regions also needs a region to list the enabled regions")
+}
+
+// newAssumeRoleCredentials creates a credentials provider that assumes -assume-role-arn using the credentials of cfg,
+// e.g. the credentials of the chosen AWS profile. If -mfa-serial is set, the role is assumed with MFA,
+// using -mfa-token or prompting for the token. The STS client uses the same endpoint settings as the other clients.
+// The assumed credentials are cached and refreshed automatically
+func newAssumeRoleCredentials(cfg aws.Config, stg settings) aws.CredentialsProvider {
+	stsClient := sts.NewFromConfig(cfg, func(o *sts.Options) {
+		setEndpointOptions(stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+	})
+
+	provider := stscreds.NewAssumeRoleProvider(stsClient, stg.assumeRoleArn, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = stg.roleSessionName
+		if stg.externalID != "" {
+			o.ExternalID = aws.String(stg.externalID)
+		}
+		if stg.mfaSerial != "" {
+			o.SerialNumber = aws.String(stg.mfaSerial)
+			o.TokenProvider = newMFATokenProvider(stg.mfaToken)
+		}
+	})
+
+	return aws.NewCredentialsCache(provider)
+}
+
+// newMFATokenProvider returns the MFA token provider used when assuming a role with MFA.
+// If mfaToken is empty, the token is prompted on stderr, so that it doesn't mix with the output written to stdout
+func newMFATokenProvider(mfaToken string) func() (string, error) {
+	if mfaToken != "" {
+		return func() (string, error) {
+			return mfaToken, nil
+		}
+	}
+
+	return promptMFAToken
+}
+
+// promptMFAToken reads the MFA token from stdin
+func promptMFAToken() (string, error) {
+	if !isTerminal(os.Stdin) {
+		return "", errors.New("the role requires MFA but stdin is not a terminal, pass the token with -mfa-token")
+	}
+
+	fmt.Fprint(os.Stderr, "MFA token code: ")
+
+	var token string
+	_, err := fmt.Fscanln(os.Stdin, &token)
+	if err != nil {
+		return "", fmt.Errorf("error when reading the MFA token: %w", err)
+	}
+
+	return strings.TrimSpace(token), nil
+}
+
+// validateMFAOptions makes sure that the MFA token can be used, since it is only used when assuming a role
+func validateMFAOptions(stg settings) error {
+	if stg.mfaSerial != "" && stg.assumeRoleArn == "" && (stg.awsProfileName == "" || stg.useEnvCreds) {
+		return errors.New("-mfa-serial requires an AWS profile that assumes a role, or -assume-role-arn")
+	}
+
+	if stg.mfaToken != "" && stg.assumeRoleArn != "" && stg.mfaSerial == "" {
+		return errors.New("-mfa-token with -assume-role-arn requires -mfa-serial")
+	}
+
+	return nil
+}
+
+// isSSOSessionExpired checks whether the error is caused by an expired or invalid SSO session,
+// which can be fixed by logging in again with aws sso login
+func isSSOSessionExpired(err error) bool {
+	var invalidTokenErr *ssocreds.InvalidTokenError
+	var unauthorizedErr *ssotypes.UnauthorizedException
+
+	return errors.As(err, &invalidTokenErr) || errors.As(err, &unauthorizedErr)
+}
+
+// wrapSSOError replaces the SDK error caused by an expired SSO session with an actionable message
+func wrapSSOError(err error, profileName string) error {
+	if err == nil || !isSSOSessionExpired(err) {
+		return err
+	}
+
+	loginCommand := "aws sso login"
+	if profileName != "" {
+		loginCommand += " --profile " + profileName
+	}
+
+	return fmt.Errorf("the SSO session has expired or is invalid, run `%s` and try again: %w", loginCommand, err)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	ssotypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
+	"go.uber.org/zap"
+)
+
+// setTestAWSEnvironment points the shared config and credentials files to a temporary directory with the test profile,
+// and sets the environment credentials, so that loadAWSConfig never uses the real AWS config of the machine
+func setTestAWSEnvironment(t *testing.T, region string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config")
+	credentialsFile := filepath.Join(dir, "credentials")
+
+	err := os.WriteFile(configFile, []byte("[profile test]\nregion = ap-southeast-1\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(credentialsFile, []byte("[test]\naws_access_key_id = PROFILEKEY\naws_secret_access_key = PROFILESECRET\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "ENVKEY")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "ENVSECRET")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_REGION", region)
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+}
+
+func TestLoadAWSConfigCredentialSources(t *testing.T) {
+	tests := []struct {
+		name          string
+		stg           settings
+		envRegion     string
+		wantAccessKey string
+		wantRegion    string
+	}{
+		{
+			name:          "profile",
+			stg:           settings{awsProfileName: "test"},
+			wantAccessKey: "PROFILEKEY",
+			wantRegion:    "ap-southeast-1",
+		},
+		{
+			name:          "environment instead of the profile",
+			stg:           settings{awsProfileName: "test", useEnvCreds: true},
+			envRegion:     "eu-west-1",
+			wantAccessKey: "ENVKEY",
+			wantRegion:    "eu-west-1",
+		},
+		{
+			name:          "environment without a profile",
+			envRegion:     "eu-west-1",
+			wantAccessKey: "ENVKEY",
+			wantRegion:    "eu-west-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestAWSEnvironment(t, tt.envRegion)
+
+			cfg, err := loadAWSConfig(context.Background(), zap.NewNop().Sugar(), tt.stg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			creds, err := cfg.Credentials.Retrieve(context.Background())
+			if err != nil {
+				t.Fatalf("error when retrieving the credentials: %v", err)
+			}
+			if creds.AccessKeyID != tt.wantAccessKey {
+				t.Errorf("access key = %q, want %q", creds.AccessKeyID, tt.wantAccessKey)
+			}
+			if cfg.Region != tt.wantRegion {
+				t.Errorf("region = %q, want %q", cfg.Region, tt.wantRegion)
+			}
+		})
+	}
+}
+
+func TestWrapSSOError(t *testing.T) {
+	// the SDK wraps the SSO errors when retrieving the credentials
+	expiredToken := fmt.Errorf("failed to refresh cached credentials: %w", &ssocreds.InvalidTokenError{Err: errors.New("the SSO session has expired")})
+	unauthorized := fmt.Errorf("failed to retrieve credentials: %w", &ssotypes.UnauthorizedException{Message: aws.String("session token not found or invalid")})
+
+	tests := []struct {
+		name        string
+		err         error
+		profileName string
+		wantExpired bool
+		wantMessage string
+	}{
+		{
+			name:        "expired SSO token",
+			err:         expiredToken,
+			profileName: "dev",
+			wantExpired: true,
+			wantMessage: "run `aws sso login --profile dev` and try again",
+		},
+		{
+			name:        "unauthorized SSO session without a profile",
+			err:         unauthorized,
+			wantExpired: true,
+			wantMessage: "run `aws sso login` and try again",
+		},
+		{
+			name:        "other error",
+			err:         errors.New("connection reset"),
+			profileName: "dev",
+			wantMessage: "connection reset",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSSOSessionExpired(tt.err); got != tt.wantExpired {
+				t.Errorf("isSSOSessionExpired() = %v, want %v", got, tt.wantExpired)
+			}
+
+			err := wrapSSOError(tt.err, tt.profileName)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("wrapSSOError() = %v, want it to wrap the original error", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantMessage) {
+				t.Errorf("wrapSSOError() = %q, want it to contain %q", err, tt.wantMessage)
+			}
+		})
+	}
+
+	if err := wrapSSOError(nil, "dev"); err != nil {
+		t.Errorf("wrapSSOError(nil) = %v, want nil", err)
+	}
+}
+
+func TestGetDefaultRegion(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfgRegion     string
+		chosenRegions []string
+		want          string
+		wantErr       bool
+	}{
+		{name: "profile region", cfgRegion: "eu-west-1", chosenRegions: []string{"us-east-1"}, want: "eu-west-1"},
+		{name: "first chosen region", cfgRegion: "", chosenRegions: []string{"us-east-1", "eu-west-1"}, want: "us-east-1"},
+		{name: "no region", cfgRegion: "", chosenRegions: []string{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getDefaultRegion(tt.cfgRegion, tt.chosenRegions)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getDefaultRegion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("getDefaultRegion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAWSConfigWithoutRegion(t *testing.T) {
+	setTestAWSEnvironment(t, "")
+
+	_, err := loadAWSConfig(context.Background(), zap.NewNop().Sugar(), settings{})
+	if err == nil || !strings.Contains(err.Error(), "no AWS region is set") {
+		t.Errorf("loadAWSConfig() = %v, want the error that tells how to set a region", err)
+	}
+
+	// the first region of -regions is used when the profile and the environment have no region
+	cfg, err := loadAWSConfig(context.Background(), zap.NewNop().Sugar(), settings{regions: "eu-central-1,us-east-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Region != "eu-central-1" {
+		t.Errorf("region = %q, want eu-central-1", cfg.Region)
+	}
+}
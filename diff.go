@@ -0,0 +1,291 @@
+package main
+
+import (
+	"cmp"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	diffChangeAdded   = "added"
+	diffChangeRemoved = "removed"
+	diffChangeChanged = "changed"
+)
+
+// diffEntry is one row of the diff report. For a changed function, there is one entry per changed column
+type diffEntry struct {
+	change   string
+	arn      string
+	name     string
+	region   string
+	column   string
+	oldValue string
+	newValue string
+}
+
+// readPreviousCSV reads the Lambda functions of a CSV file generated by a previous run, which can be gzipped.
+// It returns the functions together with the columns found in the title row, in order.
+// The titles that don't match any column are ignored, and the Function ARN column is required to match the functions
+func readPreviousCSV(fileName string, delimiter rune) ([]lambdaFunction, []string, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error when opening the previous CSV file: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(fileName, gzipFileExtension) {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error when decompressing the previous CSV file: %w", err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	cr := csv.NewReader(r)
+	if delimiter != 0 {
+		cr.Comma = delimiter
+	}
+
+	titles, err := cr.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error when reading the title row of the previous CSV file: %w", err)
+	}
+
+	columnsByTitle := map[string]string{}
+	allColumns := getColumnNames()
+	for i, title := range (lambdaFunction{}).getTitleFields(allColumns) {
+		columnsByTitle[title] = allColumns[i]
+	}
+
+	// the index of each known column in the rows of the previous CSV file
+	columnIndexes := map[string]int{}
+	columns := []string{}
+	for i, title := range titles {
+		column, ok := columnsByTitle[title]
+		if ok {
+			columnIndexes[column] = i
+			columns = append(columns, column)
+		}
+	}
+	if _, ok := columnIndexes["Arn"]; !ok {
+		return nil, nil, errors.New("the previous CSV file doesn't have the Function ARN column")
+	}
+
+	lambdaFunctionsList := []lambdaFunction{}
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error when reading the previous CSV file: %w", err)
+		}
+
+		var lambdaDetails lambdaFunction
+		value := reflect.ValueOf(&lambdaDetails).Elem()
+		for _, column := range columns {
+			err := setFieldFromString(value.FieldByName(column), row[columnIndexes[column]])
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid value of column %s in the previous CSV file: %w", column, err)
+			}
+		}
+
+		lambdaFunctionsList = append(lambdaFunctionsList, lambdaDetails)
+	}
+
+	return lambdaFunctionsList, columns, nil
+}
+
+// setFieldFromString sets the lambdaFunction field from its CSV value, which is the reverse of getRecordFields
+func setFieldFromString(field reflect.Value, s string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Map:
+		field.Set(reflect.ValueOf(parseTags(s)))
+	}
+
+	return nil
+}
+
+// parseTags parses the tags formatted by formatTags as key1=val1;key2=val2
+func parseTags(s string) map[string]string {
+	tags := map[string]string{}
+	for _, pair := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(pair, "=")
+		if ok {
+			tags[key] = value
+		}
+	}
+
+	return tags
+}
+
+// diffLambdaFunctions compares the previous and the current Lambda functions by ARN, and returns the added and removed
+// functions and one entry per changed column, sorted by region then function name. Only the chosen columns
+// are compared, which are the columns of the previous CSV file, and LastInvoked and LastInvokedAge are skipped
+// unless includeLastInvoked is set
+func diffLambdaFunctions(previous []lambdaFunction, current []lambdaFunction, columns []string, includeLastInvoked bool) []diffEntry {
+	if !includeLastInvoked {
+		columns = slices.DeleteFunc(slices.Clone(columns), func(column string) bool {
+			return column == "LastInvoked" || column == "LastInvokedAge"
+		})
+	}
+
+	previousByArn := map[string]lambdaFunction{}
+	for _, lambdaDetails := range previous {
+		previousByArn[lambdaDetails.Arn] = lambdaDetails
+	}
+
+	entries := []diffEntry{}
+	currentArns := map[string]bool{}
+	for _, lambdaDetails := range current {
+		currentArns[lambdaDetails.Arn] = true
+
+		previousDetails, ok := previousByArn[lambdaDetails.Arn]
+		if !ok {
+			entries = append(entries, newDiffEntry(diffChangeAdded, lambdaDetails))
+			continue
+		}
+
+		oldValues := previousDetails.getRecordFields(columns)
+		newValues := lambdaDetails.getRecordFields(columns)
+		for i, column := range columns {
+			if oldValues[i] != newValues[i] {
+				entry := newDiffEntry(diffChangeChanged, lambdaDetails)
+				entry.column = column
+				entry.oldValue = oldValues[i]
+				entry.newValue = newValues[i]
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	for _, lambdaDetails := range previous {
+		if !currentArns[lambdaDetails.Arn] {
+			entries = append(entries, newDiffEntry(diffChangeRemoved, lambdaDetails))
+		}
+	}
+
+	// the changed columns of a function are kept in their column order, since the sort is stable
+	slices.SortStableFunc(entries, func(a, b diffEntry) int {
+		return cmp.Or(
+			cmp.Compare(a.region, b.region),
+			cmp.Compare(a.name, b.name),
+			cmp.Compare(a.arn, b.arn),
+		)
+	})
+
+	return entries
+}
+
+// newDiffEntry creates the diff entry of the Lambda function without any column
+func newDiffEntry(change string, lambdaDetails lambdaFunction) diffEntry {
+	return diffEntry{
+		change: change,
+		arn:    lambdaDetails.Arn,
+		name:   lambdaDetails.Name,
+		region: lambdaDetails.Region,
+	}
+}
+
+// countDiffChanges returns the number of added, removed, and changed functions
+func countDiffChanges(entries []diffEntry) (int, int, int) {
+	var added, removed int
+	changedArns := map[string]bool{}
+	for _, entry := range entries {
+		switch entry.change {
+		case diffChangeAdded:
+			added++
+		case diffChangeRemoved:
+			removed++
+		case diffChangeChanged:
+			changedArns[entry.arn] = true
+		}
+	}
+
+	return added, removed, len(changedArns)
+}
+
+// writeDiffReport writes the diff report as CSV, with one row per added or removed function
+// and one row per changed column
+func writeDiffReport(w io.Writer, delimiter rune, entries []diffEntry) error {
+	cw := csv.NewWriter(w)
+	if delimiter != 0 {
+		cw.Comma = delimiter
+	}
+
+	err := cw.Write([]string{"Change", "Function ARN", "Function Name", "Region", "Column", "Old Value", "New Value"})
+	if err != nil {
+		return fmt.Errorf("error when writing title: %w", err)
+	}
+
+	for _, entry := range entries {
+		err := cw.Write([]string{entry.change, entry.arn, entry.name, entry.region, entry.column, entry.oldValue, entry.newValue})
+		if err != nil {
+			return fmt.Errorf("error when writing the diff entry for function %q: %w", entry.name, err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// getDiffFileName returns the name of the diff report file. If the user does not input a file name,
+// it returns [prefix][timestamp]-diff.csv
+func getDiffFileName(inputFileName string, prefix string) string {
+	if inputFileName != "" {
+		return inputFileName
+	}
+
+	return fmt.Sprintf("%s%d-diff.%s", prefix, time.Now().Unix(), outputFormatCSV)
+}
+
+// reportDiff compares the current Lambda functions against the functions of the previous CSV file,
+// and writes the diff report to a CSV file, or to stdout if the diff file name is -
+func (app *application) reportDiff(previous []lambdaFunction, previousColumns []string, current []lambdaFunction, delimiter rune) {
+	entries := diffLambdaFunctions(previous, current, previousColumns, app.stg.diffLastInvoked)
+	added, removed, changed := countDiffChanges(entries)
+
+	fileName := getDiffFileName(app.stg.diffOutputFileName, app.getFileNamePrefix())
+	app.logger.Infow("writing the diff report",
+		zap.String("file name", fileName),
+		zap.String("diff_against", app.stg.diffAgainst),
+		zap.Int("added_function_count", added),
+		zap.Int("removed_function_count", removed),
+		zap.Int("changed_function_count", changed),
+	)
+
+	var err error
+	if fileName == stdoutFileName {
+		err = writeDiffReport(os.Stdout, delimiter, entries)
+	} else {
+		err = writeToFile(fileName, false, outputOptions{}, func(w io.Writer, _ outputOptions) error {
+			return writeDiffReport(w, delimiter, entries)
+		})
+	}
+	if err != nil {
+		app.logger.Errorw("error when writing the diff report",
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDiffLambdaFunctions(t *testing.T) {
+	// the same function name in two regions are two functions, since they are matched by ARN
+	previous := []lambdaFunction{
+		{Arn: "arn:aws:lambda:us-east-1:123456789012:function:api", Name: "api", Region: "us-east-1", Runtime: "python3.11", MemorySize: 128, LastInvoked: "2024-05-01T12:30:00+00:00"},
+		{Arn: "arn:aws:lambda:eu-west-1:123456789012:function:api", Name: "api", Region: "eu-west-1", Runtime: "python3.11", MemorySize: 128},
+		{Arn: "arn:aws:lambda:us-east-1:123456789012:function:old", Name: "old", Region: "us-east-1"},
+	}
+	current := []lambdaFunction{
+		{Arn: "arn:aws:lambda:us-east-1:123456789012:function:api", Name: "api", Region: "us-east-1", Runtime: "python3.12", MemorySize: 256, LastInvoked: "2024-06-01T12:30:00+00:00"},
+		{Arn: "arn:aws:lambda:eu-west-1:123456789012:function:api", Name: "api", Region: "eu-west-1", Runtime: "python3.11", MemorySize: 128},
+		{Arn: "arn:aws:lambda:eu-west-1:123456789012:function:new", Name: "new", Region: "eu-west-1"},
+	}
+	columns := []string{"Name", "Region", "Arn", "Runtime", "MemorySize", "LastInvoked"}
+
+	entries := diffLambdaFunctions(previous, current, columns, false)
+	want := []diffEntry{
+		{change: diffChangeAdded, arn: "arn:aws:lambda:eu-west-1:123456789012:function:new", name: "new", region: "eu-west-1"},
+		{change: diffChangeChanged, arn: "arn:aws:lambda:us-east-1:123456789012:function:api", name: "api", region: "us-east-1", column: "Runtime", oldValue: "python3.11", newValue: "python3.12"},
+		{change: diffChangeChanged, arn: "arn:aws:lambda:us-east-1:123456789012:function:api", name: "api", region: "us-east-1", column: "MemorySize", oldValue: "128", newValue: "256"},
+		{change: diffChangeRemoved, arn: "arn:aws:lambda:us-east-1:123456789012:function:old", name: "old", region: "us-east-1"},
+	}
+	if !slices.Equal(entries, want) {
+		t.Errorf("diffLambdaFunctions() =\n%+v\nwant\n%+v", entries, want)
+	}
+
+	added, removed, changed := countDiffChanges(entries)
+	if added != 1 || removed != 1 || changed != 1 {
+		t.Errorf("countDiffChanges() = %d, %d, %d, want 1, 1, 1", added, removed, changed)
+	}
+
+	// the last invoke time is only compared with -diff-last-invoked
+	entries = diffLambdaFunctions(previous, current, columns, true)
+	if len(entries) != 5 || entries[3].column != "LastInvoked" {
+		t.Errorf("diffLambdaFunctions() with the last invoke time = %+v, want the LastInvoked change", entries)
+	}
+}
+
+func TestReadPreviousCSV(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "previous.csv")
+	lambdaFunctionsList := []lambdaFunction{
+		{Arn: "arn:aws:lambda:us-east-1:123456789012:function:api", Name: "api", Region: "us-east-1", MemorySize: 128, Tags: map[string]string{"env": "prod"}},
+	}
+	opts := outputOptions{format: outputFormatCSV, columns: []string{"Name", "Arn", "MemorySize", "Tags"}}
+
+	err := writeOutputToFile(fileName, false, opts, lambdaFunctionsList)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous, columns, err := readPreviousCSV(fileName, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(columns, opts.columns) {
+		t.Errorf("columns = %q, want %q", columns, opts.columns)
+	}
+	if entries := diffLambdaFunctions(previous, lambdaFunctionsList, columns, false); len(entries) != 0 {
+		t.Errorf("the functions read back differ from the written functions: %+v", entries)
+	}
+
+	// the functions can't be matched without the ARN
+	opts.columns = []string{"Name", "MemorySize"}
+	err = writeOutputToFile(fileName, false, opts, lambdaFunctionsList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := readPreviousCSV(fileName, 0); err == nil {
+		t.Error("expected an error without the Function ARN column")
+	}
+}
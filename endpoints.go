@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+// fipsRegions are the regions where Lambda, CloudWatch Logs, CloudWatch, and EC2 all have FIPS endpoints.
+// See https://aws.amazon.com/compliance/fips/ for the latest list
+var fipsRegions = []string{
+	"us-east-1",
+	"us-east-2",
+	"us-west-1",
+	"us-west-2",
+	"ca-central-1",
+	"ca-west-1",
+	"us-gov-east-1",
+	"us-gov-west-1",
+}
+
+// validateFIPSRegions makes sure that all regions have FIPS endpoints, since the SDK builds the FIPS endpoint
+// of any region and the calls would otherwise fail with a DNS error
+func validateFIPSRegions(regions []string) error {
+	unsupportedRegions := []string{}
+	for _, region := range regions {
+		if !slices.Contains(fipsRegions, region) {
+			unsupportedRegions = append(unsupportedRegions, region)
+		}
+	}
+
+	if len(unsupportedRegions) > 0 {
+		return fmt.Errorf("FIPS endpoints are not available in regions: %s, the regions with FIPS endpoints are %s",
+			strings.Join(unsupportedRegions, ", "), strings.Join(fipsRegions, ", "))
+	}
+
+	return nil
+}
+
+// validateEndpointURL makes sure that the custom endpoint URL is an absolute http or https URL,
+// and that it's not combined with the FIPS or dual-stack endpoints, which the custom endpoint would override
+func validateEndpointURL(endpointURL string, useFIPS bool, useDualStack bool) error {
+	if endpointURL == "" {
+		return nil
+	}
+
+	if useFIPS || useDualStack {
+		return errors.New("-endpoint-url can't be used with -use-fips or -use-dualstack")
+	}
+
+	u, err := url.Parse(endpointURL)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint URL %q: %w", endpointURL, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid endpoint URL %q, it must be an http or https URL, e.g. http://localhost:4566", endpointURL)
+	}
+
+	return nil
+}
+
+// setEndpointOptions sets the FIPS, dual-stack, and custom endpoint settings of the service clients used by the scan,
+// so that all of them use the same endpoints. Each service has its own Options type, so the fields are passed by pointer.
+// Only the settings whose flag is set are changed, so that the endpoint settings of the environment variables
+// and the shared config, e.g. AWS_USE_FIPS_ENDPOINT or AWS_ENDPOINT_URL, are kept otherwise
+func setEndpointOptions(stg settings, baseEndpointURL **string, useFIPS *aws.FIPSEndpointState, useDualStack *aws.DualStackEndpointState) {
+	if stg.endpointURL != "" {
+		*baseEndpointURL = aws.String(stg.endpointURL)
+	}
+	if stg.useFIPS {
+		*useFIPS = aws.FIPSEndpointStateEnabled
+	}
+	if stg.useDualStack {
+		*useDualStack = aws.DualStackEndpointStateEnabled
+	}
+}
+
+// resolveLambdaEndpoint returns the URL of the Lambda endpoint used in the region with the chosen endpoint settings,
+// so that it can be logged for troubleshooting. The custom endpoint URL is returned as is when it is set
+func resolveLambdaEndpoint(ctx context.Context, region string, useFIPS bool, useDualStack bool, endpointURL string) (string, error) {
+	if endpointURL != "" {
+		return endpointURL, nil
+	}
+
+	endpoint, err := lambda.NewDefaultEndpointResolverV2().ResolveEndpoint(ctx, lambda.EndpointParameters{
+		Region:       aws.String(region),
+		UseFIPS:      aws.Bool(useFIPS),
+		UseDualStack: aws.Bool(useDualStack),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return endpoint.URI.String(), nil
+}
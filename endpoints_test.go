@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+func TestDualStackEndpoint(t *testing.T) {
+	stg := settings{useDualStack: true}
+
+	client := lambda.NewFromConfig(aws.Config{Region: "us-east-1"}, func(o *lambda.Options) {
+		setEndpointOptions(stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+	})
+	options := client.Options()
+	if options.EndpointOptions.UseDualStackEndpoint != aws.DualStackEndpointStateEnabled {
+		t.Errorf("UseDualStackEndpoint = %v, want enabled", options.EndpointOptions.UseDualStackEndpoint)
+	}
+	if options.EndpointOptions.UseFIPSEndpoint != aws.FIPSEndpointStateUnset {
+		t.Errorf("UseFIPSEndpoint = %v, want unset", options.EndpointOptions.UseFIPSEndpoint)
+	}
+	if options.BaseEndpoint != nil {
+		t.Errorf("BaseEndpoint = %q, want nil", aws.ToString(options.BaseEndpoint))
+	}
+
+	endpoint, err := resolveLambdaEndpoint(context.Background(), "us-east-1", stg.useFIPS, stg.useDualStack, stg.endpointURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "https://lambda.us-east-1.api.aws"; endpoint != want {
+		t.Errorf("resolveLambdaEndpoint() = %q, want %q", endpoint, want)
+	}
+}
+
+func TestValidateEndpointURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		endpointURL  string
+		useFIPS      bool
+		useDualStack bool
+		wantErr      bool
+	}{
+		{name: "not set", endpointURL: ""},
+		{name: "not set with FIPS", endpointURL: "", useFIPS: true},
+		{name: "http", endpointURL: "http://localhost:4566"},
+		{name: "https", endpointURL: "https://lambda.example.com"},
+		{name: "no scheme", endpointURL: "localhost:4566", wantErr: true},
+		{name: "other scheme", endpointURL: "ftp://localhost:4566", wantErr: true},
+		{name: "no host", endpointURL: "http://", wantErr: true},
+		{name: "with FIPS", endpointURL: "http://localhost:4566", useFIPS: true, wantErr: true},
+		{name: "with dual-stack", endpointURL: "http://localhost:4566", useDualStack: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateEndpointURL(tt.endpointURL, tt.useFIPS, tt.useDualStack)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateEndpointURL(%q) = %v, wantErr %v", tt.endpointURL, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCustomEndpointAppliedToClients(t *testing.T) {
+	const endpointURL = "http://localhost:4566"
+	stg := settings{endpointURL: endpointURL}
+	cfg := aws.Config{Region: "us-east-1"}
+
+	baseEndpoints := map[string]*string{
+		"lambda": lambda.NewFromConfig(cfg, func(o *lambda.Options) {
+			setEndpointOptions(stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+		}).Options().BaseEndpoint,
+		"cloudwatchlogs": cloudwatchlogs.NewFromConfig(cfg, func(o *cloudwatchlogs.Options) {
+			setEndpointOptions(stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+		}).Options().BaseEndpoint,
+		"cloudwatch": cloudwatch.NewFromConfig(cfg, func(o *cloudwatch.Options) {
+			setEndpointOptions(stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+		}).Options().BaseEndpoint,
+		"ec2": ec2.NewFromConfig(cfg, func(o *ec2.Options) {
+			setEndpointOptions(stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+		}).Options().BaseEndpoint,
+		"sts": sts.NewFromConfig(cfg, func(o *sts.Options) {
+			setEndpointOptions(stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+		}).Options().BaseEndpoint,
+	}
+
+	for service, baseEndpoint := range baseEndpoints {
+		if aws.ToString(baseEndpoint) != endpointURL {
+			t.Errorf("BaseEndpoint of the %s client = %q, want %q", service, aws.ToString(baseEndpoint), endpointURL)
+		}
+	}
+
+	// the logged endpoint is the custom endpoint instead of the resolved AWS endpoint
+	endpoint, err := resolveLambdaEndpoint(context.Background(), "us-east-1", false, false, endpointURL)
+	if err != nil || endpoint != endpointURL {
+		t.Errorf("resolveLambdaEndpoint() = %q, %v, want %q", endpoint, err, endpointURL)
+	}
+}
+
+func TestEndpointOptionsKeepEnvironmentSettings(t *testing.T) {
+	setTestAWSEnvironment(t, "us-east-1")
+	t.Setenv("AWS_USE_FIPS_ENDPOINT", "true")
+	t.Setenv("AWS_ENDPOINT_URL", "http://localhost:4566")
+
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without any endpoint flag, the endpoint settings of the environment are not overwritten
+	client := lambda.NewFromConfig(cfg, func(o *lambda.Options) {
+		setEndpointOptions(settings{}, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+	})
+	options := client.Options()
+	if options.EndpointOptions.UseFIPSEndpoint != aws.FIPSEndpointStateEnabled {
+		t.Errorf("UseFIPSEndpoint = %v, want enabled from AWS_USE_FIPS_ENDPOINT", options.EndpointOptions.UseFIPSEndpoint)
+	}
+	if aws.ToString(options.BaseEndpoint) != "http://localhost:4566" {
+		t.Errorf("BaseEndpoint = %q, want the endpoint of AWS_ENDPOINT_URL", aws.ToString(options.BaseEndpoint))
+	}
+
+	// the flags still take precedence over the environment
+	client = lambda.NewFromConfig(cfg, func(o *lambda.Options) {
+		setEndpointOptions(settings{endpointURL: "http://localhost:9001"}, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+	})
+	if got := aws.ToString(client.Options().BaseEndpoint); got != "http://localhost:9001" {
+		t.Errorf("BaseEndpoint = %q, want the endpoint of -endpoint-url", got)
+	}
+}
+
+func TestCustomEndpointAppliedToAssumeRoleAndS3(t *testing.T) {
+	const endpointURL = "http://localhost:4566"
+	stg := settings{endpointURL: endpointURL, assumeRoleArn: "arn:aws:iam::123456789012:role/lister", roleSessionName: defaultRoleSessionName}
+
+	// the AssumeRole call is sent to the custom endpoint, the response doesn't matter
+	httpClient := &fakeHTTPClient{responses: []fakeHTTPResponse{{statusCode: http.StatusForbidden, body: "<ErrorResponse/>"}}}
+	cfg := newTestConfig(httpClient)
+	cfg.Retryer = func() aws.Retryer { return aws.NopRetryer{} }
+
+	_, _ = newAssumeRoleCredentials(cfg, stg).Retrieve(context.Background())
+	if len(httpClient.requests) == 0 {
+		t.Fatal("AssumeRole is not called")
+	}
+	if host := httpClient.requests[0].URL.Host; host != "localhost:4566" {
+		t.Errorf("AssumeRole is sent to %q, want localhost:4566", host)
+	}
+
+	app := newTestApplication()
+	app.stg = stg
+	options := s3.NewFromConfig(cfg, app.setS3Options("eu-west-1")).Options()
+	if aws.ToString(options.BaseEndpoint) != endpointURL || !options.UsePathStyle || options.Region != "eu-west-1" {
+		t.Errorf("S3 options = %q, path style %v, region %q, want %q with path style in eu-west-1",
+			aws.ToString(options.BaseEndpoint), options.UsePathStyle, options.Region, endpointURL)
+	}
+
+	// the S3 client uses the FIPS endpoints like the other clients
+	app.stg = settings{useFIPS: true}
+	options = s3.NewFromConfig(cfg, app.setS3Options("")).Options()
+	if options.EndpointOptions.UseFIPSEndpoint != aws.FIPSEndpointStateEnabled || options.UsePathStyle || options.Region != "us-east-1" {
+		t.Errorf("S3 options with -use-fips = %v, path style %v, region %q, want FIPS enabled in us-east-1",
+			options.EndpointOptions.UseFIPSEndpoint, options.UsePathStyle, options.Region)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+)
+
+// defaultFatalErrorCodes are the error codes returned by the AWS APIs when the credentials are invalid or expired,
+// in which case all remaining calls would fail the same way
+const defaultFatalErrorCodes = "UnrecognizedClientException,InvalidSignatureException,ExpiredTokenException,InvalidClientTokenId"
+
+// accessDeniedErrorCodes are the error codes returned by the AWS APIs when the credentials are not allowed
+// to call the operation
+var accessDeniedErrorCodes = []string{"AccessDeniedException", "AccessDenied", "UnauthorizedOperation"}
+
+// optInRequiredErrorCode is the error code returned by the AWS APIs when the region is not enabled for the account,
+// e.g. when an opt-in region is disabled after the regions are listed
+const optInRequiredErrorCode = "OptInRequired"
+
+// ec2AuthFailureErrorCode is the error code returned by EC2 when the region is not enabled for the account.
+// The other services return the same error codes as for invalid credentials, which are fatal errors instead
+const ec2AuthFailureErrorCode = "AuthFailure"
+
+// regionUnavailableMessages are the messages of the errors returned by the SDK when the endpoint of the region can't be resolved
+var regionUnavailableMessages = []string{"failed to resolve service endpoint", "unable to resolve endpoint"}
+
+// runError contains the details of an error encountered while gathering
+// the Lambda function details, which will be printed in the error summary
+type runError struct {
+	region       string
+	functionName string
+	operation    string
+	err          error
+}
+
+// recordError stores the error so that it can be reported in the error summary at the end of the run.
+// functionName can be empty if the error is not specific to a function, e.g. when listing the functions of a region.
+// It is safe to be called concurrently by the worker goroutines
+func (app *application) recordError(region string, functionName string, operation string, err error) {
+	app.addRegionError(region)
+
+	app.runErrorsMu.Lock()
+	defer app.runErrorsMu.Unlock()
+
+	app.runErrors = append(app.runErrors, runError{
+		region:       region,
+		functionName: functionName,
+		operation:    operation,
+		err:          err,
+	})
+}
+
+// getErrors returns a copy of all errors recorded so far
+func (app *application) getErrors() []runError {
+	app.runErrorsMu.Lock()
+	defer app.runErrorsMu.Unlock()
+
+	runErrors := make([]runError, len(app.runErrors))
+	copy(runErrors, app.runErrors)
+
+	return runErrors
+}
+
+// printErrorSummary writes the recorded errors as a table to w
+func printErrorSummary(w io.Writer, runErrors []runError) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "%d error(s) encountered:\n", len(runErrors))
+	fmt.Fprintln(tw, "REGION\tFUNCTION\tOPERATION\tERROR")
+	for _, runErr := range runErrors {
+		functionName := runErr.functionName
+		if functionName == "" {
+			functionName = "-"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%v\n", runErr.region, functionName, runErr.operation, runErr.err)
+	}
+
+	return tw.Flush()
+}
+
+// isFatalError checks whether the error code is one of fatalErrorCodes, which stops the last invoke phase
+func isFatalError(err error, fatalErrorCodes []string) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return slices.Contains(fatalErrorCodes, apiErr.ErrorCode())
+}
+
+// isAccessDenied checks whether the error is returned because the credentials are not allowed to call the operation
+func isAccessDenied(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return slices.Contains(accessDeniedErrorCodes, apiErr.ErrorCode())
+}
+
+// isRegionUnavailable checks whether the error is returned because the region is not enabled for the account
+// or its endpoint can't be resolved, in which case all calls to the region would fail the same way.
+// The fatal errors must be checked first, since a disabled region can't be told apart from invalid credentials
+// for most services
+func isRegionUnavailable(err error) bool {
+	var endpointErr *aws.EndpointNotFoundError
+	if errors.As(err, &endpointErr) {
+		return true
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.ErrorCode() == optInRequiredErrorCode {
+			return true
+		}
+
+		var oe *smithy.OperationError
+		if apiErr.ErrorCode() == ec2AuthFailureErrorCode && errors.As(err, &oe) && oe.Service() == "EC2" {
+			return true
+		}
+	}
+
+	for _, message := range regionUnavailableMessages {
+		if strings.Contains(err.Error(), message) {
+			return true
+		}
+	}
+
+	return false
+}
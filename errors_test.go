@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+)
+
+func TestIsAccessDenied(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "Lambda access denied", err: &smithy.GenericAPIError{Code: "AccessDeniedException"}, want: true},
+		{name: "STS access denied", err: &smithy.GenericAPIError{Code: "AccessDenied"}, want: true},
+		{name: "EC2 unauthorized operation", err: &smithy.GenericAPIError{Code: "UnauthorizedOperation"}, want: true},
+		{
+			name: "wrapped access denied",
+			err:  fmt.Errorf("error when listing functions in region us-east-1: %w", &smithy.GenericAPIError{Code: "AccessDeniedException"}),
+			want: true,
+		},
+		{name: "throttled", err: &smithy.GenericAPIError{Code: "TooManyRequestsException"}, want: false},
+		{name: "not an API error", err: errors.New("access denied"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAccessDenied(tt.err); got != tt.want {
+				t.Errorf("isAccessDenied(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRegionUnavailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "endpoint not found", err: &aws.EndpointNotFoundError{Err: errors.New("no endpoint")}, want: true},
+		{name: "opt-in required", err: &smithy.GenericAPIError{Code: optInRequiredErrorCode}, want: true},
+		{
+			name: "EC2 auth failure",
+			err:  &smithy.OperationError{ServiceID: "EC2", OperationName: "DescribeRegions", Err: &smithy.GenericAPIError{Code: ec2AuthFailureErrorCode}},
+			want: true,
+		},
+		{
+			// the other services return AuthFailure for invalid credentials
+			name: "auth failure of another service",
+			err:  &smithy.OperationError{ServiceID: "Lambda", OperationName: "ListFunctions", Err: &smithy.GenericAPIError{Code: ec2AuthFailureErrorCode}},
+			want: false,
+		},
+		{name: "failed to resolve service endpoint", err: errors.New("failed to resolve service endpoint, an AWS region is required"), want: true},
+		{name: "unable to resolve endpoint", err: fmt.Errorf("error when listing functions: %w", errors.New("unable to resolve endpoint for region xx-west-1")), want: true},
+		{name: "access denied", err: &smithy.GenericAPIError{Code: "AccessDeniedException"}, want: false},
+		{name: "other error", err: errors.New("connection reset"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRegionUnavailable(tt.err); got != tt.want {
+				t.Errorf("isRegionUnavailable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// filterByRuntime returns the Lambda functions whose runtime matches one of the chosen runtimes.
+// The comparison is case-insensitive. If no runtime is chosen, all functions are returned
+func filterByRuntime(lambdaFunctionsList []lambdaFunction, runtimes []string) []lambdaFunction {
+	if len(runtimes) == 0 {
+		return lambdaFunctionsList
+	}
+
+	filteredList := []lambdaFunction{}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		for _, runtime := range runtimes {
+			if strings.EqualFold(lambdaDetails.Runtime, runtime) {
+				filteredList = append(filteredList, lambdaDetails)
+				break
+			}
+		}
+	}
+
+	return filteredList
+}
+
+// tagFilter is one tag key and value pair of -tag-filter
+type tagFilter struct {
+	key   string
+	value string
+}
+
+// parseTagFilters parses the key=value pairs of -tag-filter. The value can be empty to match a tag with an empty value
+func parseTagFilters(inputs []string) ([]tagFilter, error) {
+	filters := []tagFilter{}
+	for _, input := range inputs {
+		key, value, ok := strings.Cut(input, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag filter %q, it must be key=value", input)
+		}
+
+		filters = append(filters, tagFilter{key: key, value: value})
+	}
+
+	return filters, nil
+}
+
+// filterByTags returns the Lambda functions that have all the tag key and value pairs.
+// The functions without the tags, including the ones which tags couldn't be listed, are excluded.
+// If no tag filter is chosen, all functions are returned
+func filterByTags(lambdaFunctionsList []lambdaFunction, filters []tagFilter) []lambdaFunction {
+	if len(filters) == 0 {
+		return lambdaFunctionsList
+	}
+
+	filteredList := []lambdaFunction{}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		matched := true
+		for _, filter := range filters {
+			value, ok := lambdaDetails.Tags[filter.key]
+			if !ok || value != filter.value {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			filteredList = append(filteredList, lambdaDetails)
+		}
+	}
+
+	return filteredList
+}
+
+// filterByName returns the Lambda functions whose name matches the regular expression.
+// If the regular expression is nil, all functions are returned
+func filterByName(lambdaFunctionsList []lambdaFunction, nameFilter *regexp.Regexp) []lambdaFunction {
+	if nameFilter == nil {
+		return lambdaFunctionsList
+	}
+
+	filteredList := []lambdaFunction{}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		if nameFilter.MatchString(lambdaDetails.Name) {
+			filteredList = append(filteredList, lambdaDetails)
+		}
+	}
+
+	return filteredList
+}
+
+// filterByMaxAge returns the stale Lambda functions, which are the functions that were not invoked
+// in the last maxAgeDays days counted from now. Functions that were never invoked ("-") are stale,
+// and so are functions whose last invoke time can't be parsed, since they can't be proven to be recent.
+// If maxAgeDays is 0 or less, all functions are returned
+func filterByMaxAge(lambdaFunctionsList []lambdaFunction, maxAgeDays int, now time.Time) []lambdaFunction {
+	if maxAgeDays <= 0 {
+		return lambdaFunctionsList
+	}
+
+	threshold := now.AddDate(0, 0, -maxAgeDays)
+
+	filteredList := []lambdaFunction{}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		lastInvoked, err := time.Parse(lastInvokedTimeFormat, strings.TrimSpace(lambdaDetails.LastInvoked))
+		if err != nil || lastInvoked.Before(threshold) {
+			filteredList = append(filteredList, lambdaDetails)
+		}
+	}
+
+	return filteredList
+}
+
+// filterNeverInvoked returns the Lambda functions that have a last invoke time,
+// dropping the functions that were never invoked ("-")
+func filterNeverInvoked(lambdaFunctionsList []lambdaFunction) []lambdaFunction {
+	filteredList := []lambdaFunction{}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		if lambdaDetails.LastInvoked != "-" {
+			filteredList = append(filteredList, lambdaDetails)
+		}
+	}
+
+	return filteredList
+}
+
+// filterByLastInvoke applies the filters that depend on the last invoke time,
+// which are -include-never-invoked and -max-age-days
+func filterByLastInvoke(lambdaFunctionsList []lambdaFunction, stg settings, now time.Time) []lambdaFunction {
+	if !stg.includeNeverInvoked {
+		lambdaFunctionsList = filterNeverInvoked(lambdaFunctionsList)
+	}
+
+	return filterByMaxAge(lambdaFunctionsList, stg.maxAgeDays, now)
+}
+
+// filterDeprecatedRuntimes returns the Lambda functions with a deprecated or deprecating runtime.
+// If onlyDeprecated is not set, all functions are returned
+func filterDeprecatedRuntimes(lambdaFunctionsList []lambdaFunction, onlyDeprecated bool) []lambdaFunction {
+	if !onlyDeprecated {
+		return lambdaFunctionsList
+	}
+
+	filteredList := []lambdaFunction{}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		if isDeprecatedRuntimeStatus(lambdaDetails.RuntimeStatus) {
+			filteredList = append(filteredList, lambdaDetails)
+		}
+	}
+
+	return filteredList
+}
+
+// filterByModifiedAfter returns the Lambda functions that were last modified after modifiedAfter.
+// The functions whose last modified time can't be parsed are kept, and their names are returned
+// as the second value so that they can be reported.
+// If modifiedAfter is zero, all functions are returned
+func filterByModifiedAfter(lambdaFunctionsList []lambdaFunction, modifiedAfter time.Time) ([]lambdaFunction, []string) {
+	if modifiedAfter.IsZero() {
+		return lambdaFunctionsList, nil
+	}
+
+	filteredList := []lambdaFunction{}
+	unparseableNames := []string{}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		lastModified, err := time.Parse(lastModifiedTimeFormat, lambdaDetails.LastModified)
+		if err != nil {
+			unparseableNames = append(unparseableNames, lambdaDetails.Name)
+			filteredList = append(filteredList, lambdaDetails)
+		} else if lastModified.After(modifiedAfter) {
+			filteredList = append(filteredList, lambdaDetails)
+		}
+	}
+
+	return filteredList, unparseableNames
+}
+
+// sampleLambdaFunctions returns sampleSize Lambda functions picked at random, in their original order.
+// The same seed always picks the same functions from the same list.
+// If sampleSize is 0 or not less than the number of functions, all functions are returned
+func sampleLambdaFunctions(lambdaFunctionsList []lambdaFunction, sampleSize int, seed uint64) []lambdaFunction {
+	if sampleSize <= 0 || sampleSize >= len(lambdaFunctionsList) {
+		return lambdaFunctionsList
+	}
+
+	r := rand.New(rand.NewPCG(seed, seed))
+
+	indexes := r.Perm(len(lambdaFunctionsList))[:sampleSize]
+	slices.Sort(indexes)
+
+	sampledList := make([]lambdaFunction, 0, sampleSize)
+	for _, i := range indexes {
+		sampledList = append(sampledList, lambdaFunctionsList[i])
+	}
+
+	return sampledList
+}
+
+// parseDate parses the user input date which is either in RFC3339 format, e.g. 2025-04-18T15:30:00Z,
+// or in YYYY-MM-DD format, which is the start of the day in UTC
+func parseDate(input string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, input)
+	if err == nil {
+		return t, nil
+	}
+
+	t, err = time.Parse(time.DateOnly, input)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use RFC3339 (e.g. 2025-04-18T15:30:00Z) or YYYY-MM-DD format", input)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// functionNames returns the names of the Lambda functions, in order
+func functionNames(lambdaFunctionsList []lambdaFunction) []string {
+	names := []string{}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		names = append(names, lambdaDetails.Name)
+	}
+
+	return names
+}
+
+func TestFilterNeverInvoked(t *testing.T) {
+	lambdaFunctionsList := []lambdaFunction{
+		{Name: "invoked", LastInvoked: "2024-05-01T12:30:00+00:00"},
+		{Name: "never-invoked", LastInvoked: "-"},
+		{Name: "skipped", LastInvoked: lastInvokedSkipped},
+	}
+
+	got := functionNames(filterNeverInvoked(lambdaFunctionsList))
+	if want := []string{"invoked", "skipped"}; !slices.Equal(got, want) {
+		t.Errorf("filterNeverInvoked() = %q, want %q", got, want)
+	}
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	got = functionNames(filterByLastInvoke(lambdaFunctionsList, settings{includeNeverInvoked: true}, now))
+	if want := []string{"invoked", "never-invoked", "skipped"}; !slices.Equal(got, want) {
+		t.Errorf("filterByLastInvoke() with -include-never-invoked = %q, want %q", got, want)
+	}
+}
+
+func TestFilterByMaxAge(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	lambdaFunctionsList := []lambdaFunction{
+		{Name: "recent", LastInvoked: now.AddDate(0, 0, -1).Format(lastInvokedTimeFormat)},
+		{Name: "just-within", LastInvoked: now.AddDate(0, 0, -30).Add(time.Minute).Format(lastInvokedTimeFormat)},
+		{Name: "stale", LastInvoked: now.AddDate(0, 0, -31).Format(lastInvokedTimeFormat)},
+		{Name: "never-invoked", LastInvoked: "-"},
+		{Name: "unparsable", LastInvoked: "yesterday"},
+	}
+
+	tests := []struct {
+		name       string
+		maxAgeDays int
+		want       []string
+	}{
+		{name: "disabled", maxAgeDays: 0, want: []string{"recent", "just-within", "stale", "never-invoked", "unparsable"}},
+		{name: "30 days", maxAgeDays: 30, want: []string{"stale", "never-invoked", "unparsable"}},
+		{name: "1 day", maxAgeDays: 1, want: []string{"just-within", "stale", "never-invoked", "unparsable"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := functionNames(filterByMaxAge(lambdaFunctionsList, tt.maxAgeDays, now))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("filterByMaxAge() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterByMaxAgeWithLookbackDays(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	lambdaFunctionsList := []lambdaFunction{
+		{Name: "recent", LastInvoked: now.AddDate(0, 0, -10).Format(lastInvokedTimeFormat)},
+		{Name: "inactive", LastInvoked: now.AddDate(0, 0, -100).Format(lastInvokedTimeFormat)},
+	}
+
+	// the inactive label is only written in the output, so a function labeled inactive by -lookback-days 7
+	// is still compared with its exact time, and the one invoked 10 days ago is not stale with -max-age-days 30
+	got := functionNames(filterByMaxAge(lambdaFunctionsList, 30, now))
+	if want := []string{"inactive"}; !slices.Equal(got, want) {
+		t.Errorf("filterByMaxAge() = %q, want %q", got, want)
+	}
+
+	formatLastInvokedTimes(lambdaFunctionsList, timeFormatRFC3339, 7, now)
+	for _, lambdaDetails := range lambdaFunctionsList {
+		if lambdaDetails.LastInvoked != inactiveLabel(7) {
+			t.Errorf("last invoke time of %q = %q, want %q", lambdaDetails.Name, lambdaDetails.LastInvoked, inactiveLabel(7))
+		}
+	}
+}
+
+func TestFilterByModifiedAfter(t *testing.T) {
+	modifiedAfter := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	lambdaFunctionsList := []lambdaFunction{
+		{Name: "before", LastModified: "2024-05-01T11:59:59.999+0000"},
+		{Name: "at-the-boundary", LastModified: "2024-05-01T12:00:00.000+0000"},
+		{Name: "just-after", LastModified: "2024-05-01T12:00:00.001+0000"},
+		{Name: "after-in-another-offset", LastModified: "2024-05-01T14:30:00.000+0200"},
+		{Name: "malformed", LastModified: "2024-05-01"},
+	}
+
+	got, unparseableNames := filterByModifiedAfter(lambdaFunctionsList, modifiedAfter)
+
+	// the boundary is exclusive, and the functions with a malformed time are kept and reported
+	if want := []string{"just-after", "after-in-another-offset", "malformed"}; !slices.Equal(functionNames(got), want) {
+		t.Errorf("filterByModifiedAfter() = %q, want %q", functionNames(got), want)
+	}
+	if want := []string{"malformed"}; !slices.Equal(unparseableNames, want) {
+		t.Errorf("unparseable names = %q, want %q", unparseableNames, want)
+	}
+
+	all, unparseableNames := filterByModifiedAfter(lambdaFunctionsList, time.Time{})
+	if len(all) != len(lambdaFunctionsList) || len(unparseableNames) != 0 {
+		t.Errorf("filterByModifiedAfter() without a date = %q and %q, want all functions", functionNames(all), unparseableNames)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{input: "2025-04-18T15:30:00Z", want: time.Date(2025, 4, 18, 15, 30, 0, 0, time.UTC)},
+		{input: "2025-04-18T15:30:00+02:00", want: time.Date(2025, 4, 18, 13, 30, 0, 0, time.UTC)},
+		{input: "2025-04-18", want: time.Date(2025, 4, 18, 0, 0, 0, 0, time.UTC)},
+		{input: "18/04/2025", wantErr: true},
+		{input: "2025-04-18 15:30", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseDate(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseDate(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterByTags(t *testing.T) {
+	lambdaFunctionsList := []lambdaFunction{
+		{Name: "both", Tags: map[string]string{"env": "prod", "team": "payments"}},
+		{Name: "env-only", Tags: map[string]string{"env": "prod"}},
+		{Name: "other-env", Tags: map[string]string{"env": "dev", "team": "payments"}},
+		{Name: "empty-value", Tags: map[string]string{"env": "", "team": "payments"}},
+		{Name: "no-tags"},
+	}
+
+	tests := []struct {
+		name    string
+		filters []string
+		want    []string
+	}{
+		{name: "no filter", filters: nil, want: []string{"both", "env-only", "other-env", "empty-value", "no-tags"}},
+		{name: "one tag", filters: []string{"env=prod"}, want: []string{"both", "env-only"}},
+		{name: "all tags must match", filters: []string{"env=prod", "team=payments"}, want: []string{"both"}},
+		{name: "empty value", filters: []string{"env="}, want: []string{"empty-value"}},
+		{name: "value with equal sign", filters: []string{"env=prod=1"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := parseTagFilters(tt.filters)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := functionNames(filterByTags(lambdaFunctionsList, filters))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("filterByTags(%q) = %q, want %q", tt.filters, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTagFilters(t *testing.T) {
+	filters, err := parseTagFilters([]string{"env=prod", "url=https://example.com/?a=b", "empty="})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []tagFilter{{key: "env", value: "prod"}, {key: "url", value: "https://example.com/?a=b"}, {key: "empty", value: ""}}
+	if !slices.Equal(filters, want) {
+		t.Errorf("parseTagFilters() = %+v, want %+v", filters, want)
+	}
+
+	for _, input := range []string{"env", "=prod", ""} {
+		if _, err := parseTagFilters([]string{input}); err == nil {
+			t.Errorf("parseTagFilters(%q) = nil error, want an error", input)
+		}
+	}
+}
+
+func TestSampleLambdaFunctions(t *testing.T) {
+	lambdaFunctionsList := []lambdaFunction{}
+	for _, functionDetail := range functionConfigurations("function", 20) {
+		lambdaFunctionsList = append(lambdaFunctionsList, newLambdaFunction(functionDetail, "us-east-1"))
+	}
+	names := functionNames(lambdaFunctionsList)
+
+	sampled := functionNames(sampleLambdaFunctions(lambdaFunctionsList, 5, 42))
+	if len(sampled) != 5 {
+		t.Fatalf("sampled %d functions, want 5", len(sampled))
+	}
+
+	// the sampled functions are kept in their original order
+	previousIndex := -1
+	for _, name := range sampled {
+		index := slices.Index(names, name)
+		if index <= previousIndex {
+			t.Errorf("sampled functions %q are not in the original order", sampled)
+			break
+		}
+		previousIndex = index
+	}
+
+	if again := functionNames(sampleLambdaFunctions(lambdaFunctionsList, 5, 42)); !slices.Equal(again, sampled) {
+		t.Errorf("the same seed sampled %q and %q, want the same functions", sampled, again)
+	}
+	if other := functionNames(sampleLambdaFunctions(lambdaFunctionsList, 5, 43)); slices.Equal(other, sampled) {
+		t.Errorf("another seed sampled the same functions %q", other)
+	}
+
+	for _, sampleSize := range []int{0, 20, 30} {
+		if got := sampleLambdaFunctions(lambdaFunctionsList, sampleSize, 42); len(got) != len(lambdaFunctionsList) {
+			t.Errorf("sampleLambdaFunctions() with sample size %d returned %d functions, want all", sampleSize, len(got))
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"go.uber.org/zap"
+)
+
+// functionEntry is a function listed in the functions file
+type functionEntry struct {
+	name   string
+	region string
+}
+
+// readFunctionsFile reads the functions file, which has one functionName,region pair per line.
+// The region can be omitted, in which case defaultRegion is used. Empty lines and lines starting with # are skipped
+func readFunctionsFile(fileName string, defaultRegion string) ([]functionEntry, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("error when opening the functions file: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	entries := []functionEntry{}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error when reading the functions file: %w", err)
+		}
+
+		line, _ := r.FieldPos(0)
+		if len(record) > 2 {
+			return nil, fmt.Errorf("invalid entry on line %d of the functions file, expected functionName,region", line)
+		}
+
+		entry := functionEntry{
+			name:   strings.TrimSpace(record[0]),
+			region: defaultRegion,
+		}
+		if len(record) == 2 && strings.TrimSpace(record[1]) != "" {
+			entry.region = strings.TrimSpace(record[1])
+		}
+
+		if entry.name == "" {
+			return nil, fmt.Errorf("missing function name on line %d of the functions file", line)
+		}
+		if entry.region == "" {
+			return nil, fmt.Errorf("missing region on line %d of the functions file, and there's no default region", line)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// getFunctionsFileRegions returns the unique regions of the functions file entries, in order of appearance
+func getFunctionsFileRegions(entries []functionEntry) []string {
+	regions := []string{}
+	for _, entry := range entries {
+		if !slices.Contains(regions, entry.region) {
+			regions = append(regions, entry.region)
+		}
+	}
+
+	return regions
+}
+
+// getLambdaFunctionsFromFile gets the details of the functions listed in the functions file concurrently,
+// instead of listing all functions of the regions. The functions that don't exist are skipped with a warning
+func (app *application) getLambdaFunctionsFromFile(ctx context.Context, entries []functionEntry, maxWorkers int) []lambdaFunction {
+	app.logger.Infow("getting the details of the functions in the functions file",
+		zap.Int("function_count", len(entries)),
+	)
+
+	entryJobs := make(chan int)
+	go func() {
+		defer close(entryJobs)
+		for i := range entries {
+			select {
+			case entryJobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// functions is indexed the same as entries, and is nil for the functions that can't be found
+	functions := make([]*lambdaFunction, len(entries))
+
+	wg := &sync.WaitGroup{}
+
+	for range maxWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range entryJobs {
+				functions[i] = app.getLambdaFunctionFromEntry(ctx, entries[i])
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	lambdaFunctionsList := []lambdaFunction{}
+	for _, f := range functions {
+		if f != nil {
+			lambdaFunctionsList = append(lambdaFunctionsList, *f)
+		}
+	}
+
+	slices.SortFunc(lambdaFunctionsList, func(a, b lambdaFunction) int {
+		return cmp.Or(
+			cmp.Compare(a.Region, b.Region),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+
+	app.logger.Infow("got the details of the functions in the functions file",
+		zap.Int("function_count", len(lambdaFunctionsList)),
+	)
+
+	return lambdaFunctionsList
+}
+
+// getLambdaFunctionFromEntry gets the configuration of the function in the functions file entry.
+// It returns nil if the function doesn't exist or there's an error
+func (app *application) getLambdaFunctionFromEntry(ctx context.Context, entry functionEntry) *lambdaFunction {
+	lambdaClient := app.getLambdaClient(entry.region)
+
+	out, err := lambdaClient.GetFunctionConfiguration(ctx, &lambda.GetFunctionConfigurationInput{
+		FunctionName: aws.String(entry.name),
+	})
+	if err != nil {
+		var rnf *lambdatypes.ResourceNotFoundException
+		if errors.As(err, &rnf) {
+			app.logger.Warnw("lambda function in the functions file does not exist",
+				zap.String("function_name", entry.name),
+				zap.String("region", entry.region),
+			)
+			return nil
+		}
+
+		app.logger.Debugw("error when getting function configuration",
+			zap.String("function_name", entry.name),
+			zap.Error(err),
+		)
+		app.recordError(entry.region, entry.name, "GetFunctionConfiguration", err)
+		return nil
+	}
+
+	f := newLambdaFunction(lambdatypes.FunctionConfiguration{
+		Architectures:            out.Architectures,
+		CodeSha256:               out.CodeSha256,
+		CodeSize:                 out.CodeSize,
+		DeadLetterConfig:         out.DeadLetterConfig,
+		Description:              out.Description,
+		Environment:              out.Environment,
+		EphemeralStorage:         out.EphemeralStorage,
+		FunctionArn:              out.FunctionArn,
+		FunctionName:             out.FunctionName,
+		Handler:                  out.Handler,
+		Layers:                   out.Layers,
+		LastModified:             out.LastModified,
+		LastUpdateStatus:         out.LastUpdateStatus,
+		LoggingConfig:            out.LoggingConfig,
+		MemorySize:               out.MemorySize,
+		PackageType:              out.PackageType,
+		RevisionId:               out.RevisionId,
+		Role:                     out.Role,
+		Runtime:                  out.Runtime,
+		SigningProfileVersionArn: out.SigningProfileVersionArn,
+		SnapStart:                out.SnapStart,
+		State:                    out.State,
+		Timeout:                  out.Timeout,
+		TracingConfig:            out.TracingConfig,
+		Version:                  out.Version,
+		VpcConfig:                out.VpcConfig,
+	}, entry.region)
+	f.AccountId = app.accountId
+	f.Profile = app.profileName
+
+	return &f
+}
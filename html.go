@@ -0,0 +1,43 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+//go:embed templates/report.html
+var htmlReportTemplate string
+
+// htmlReport contains the data rendered by the HTML report template
+type htmlReport struct {
+	GeneratedAt string
+	Titles      []string
+	Rows        [][]string
+}
+
+// writeHTML writes an HTML page with a table of the chosen columns, which can be sorted by clicking the column titles.
+// The values are escaped by html/template
+func writeHTML(w io.Writer, opts outputOptions, lambdaFunctionsList []lambdaFunction) error {
+	tmpl, err := template.New("report").Parse(htmlReportTemplate)
+	if err != nil {
+		return fmt.Errorf("error when parsing the HTML template: %w", err)
+	}
+
+	report := htmlReport{
+		GeneratedAt: time.Now().Format(time.RFC1123),
+		Titles:      lambdaFunction{}.getTitleFields(opts.columns),
+	}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		report.Rows = append(report.Rows, lambdaDetails.getRecordFields(opts.columns))
+	}
+
+	err = tmpl.Execute(w, report)
+	if err != nil {
+		return fmt.Errorf("error when writing the HTML report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"go.uber.org/zap"
+)
+
+// getRoleName returns the name of the IAM role, which is the last path segment of the role ARN,
+// e.g. arn:aws:iam::123456789012:role/service-role/my-role becomes my-role
+func getRoleName(roleArn string) string {
+	if roleArn == "-" {
+		return "-"
+	}
+
+	return roleArn[strings.LastIndex(roleArn, "/")+1:]
+}
+
+// rolePoliciesLookup is the lookup of the attached managed policy names of one IAM role,
+// which is only done once for all the functions sharing the role
+type rolePoliciesLookup struct {
+	once        sync.Once
+	policyNames []string
+	err         error
+}
+
+// rolePoliciesCache contains the policy lookups keyed by role ARN, since the roles with the same name
+// in different accounts or paths are different roles
+type rolePoliciesCache struct {
+	mu      sync.Mutex
+	lookups map[string]*rolePoliciesLookup
+}
+
+// get returns the lookup of the role, which is created on the first call for the role ARN
+func (c *rolePoliciesCache) get(roleArn string) *rolePoliciesLookup {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lookup, ok := c.lookups[roleArn]
+	if !ok {
+		lookup = &rolePoliciesLookup{}
+		c.lookups[roleArn] = lookup
+	}
+
+	return lookup
+}
+
+// getAllLambdaFunctionsRolePolicies lists the attached managed policies of the IAM roles of the Lambda functions
+// concurrently using runPerFunction. The policies of each role are only listed once, even if the role is shared
+// by multiple functions, and a failed lookup is only recorded as an error for the function that made the call
+func (app *application) getAllLambdaFunctionsRolePolicies(ctx context.Context, lambdaFunctionsList []lambdaFunction, jobs <-chan job, maxWorkers int) {
+	app.logger.Info("getting role policies for all lambda functions")
+
+	cache := &rolePoliciesCache{lookups: map[string]*rolePoliciesLookup{}}
+	app.runPerFunction(ctx, lambdaFunctionsList, jobs, maxWorkers, "ListAttachedRolePolicies", func(ctx context.Context, currentJob job) (func(*lambdaFunction), error) {
+		lambdaDetails := lambdaFunctionsList[currentJob.index]
+		if lambdaDetails.IamRole == "-" {
+			return nil, nil
+		}
+
+		return app.getLambdaFunctionRolePolicies(ctx, cache.get(lambdaDetails.IamRole), lambdaDetails.IamRole)
+	}, nil)
+
+	app.logger.Infow("got role policies for all lambda functions",
+		zap.Int("role_count", len(cache.lookups)),
+	)
+}
+
+// getLambdaFunctionRolePolicies lists the policies of the role on the first call for the role, and waits for
+// that call otherwise. Only the first call returns the error of a failed lookup, so that it is recorded once per role
+func (app *application) getLambdaFunctionRolePolicies(ctx context.Context, lookup *rolePoliciesLookup, roleArn string) (func(*lambdaFunction), error) {
+	called := false
+	lookup.once.Do(func() {
+		called = true
+		lookup.policyNames, lookup.err = app.getRolePolicyNames(ctx, getRoleName(roleArn))
+		if lookup.err != nil {
+			lookup.err = fmt.Errorf("error when listing the attached policies of role %q: %w", roleArn, lookup.err)
+		}
+	})
+
+	if lookup.err != nil {
+		if called {
+			return nil, lookup.err
+		}
+		return nil, nil
+	}
+
+	return func(lambdaDetails *lambdaFunction) {
+		lambdaDetails.RolePolicies = strings.Join(lookup.policyNames, ";")
+	}, nil
+}
+
+// getRolePolicyNames returns the names of all managed policies attached to the IAM role
+func (app *application) getRolePolicyNames(ctx context.Context, roleName string) ([]string, error) {
+	policyNames := []string{}
+	in := &iam.ListAttachedRolePoliciesInput{
+		RoleName: aws.String(roleName),
+	}
+
+	for {
+		out, err := app.iamClient.ListAttachedRolePolicies(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, policy := range out.AttachedPolicies {
+			policyNames = append(policyNames, aws.ToString(policy.PolicyName))
+		}
+
+		if !out.IsTruncated {
+			return policyNames, nil
+		}
+		in.Marker = out.Marker
+	}
+}
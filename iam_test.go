@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/smithy-go"
+)
+
+// fakeRolePolicyLister returns the policy names keyed by role name, or the error of the role if it is set
+type fakeRolePolicyLister struct {
+	policies map[string][]string
+	errs     map[string]error
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeRolePolicyLister) ListAttachedRolePolicies(ctx context.Context, params *iam.ListAttachedRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListAttachedRolePoliciesOutput, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+
+	roleName := aws.ToString(params.RoleName)
+	if err := f.errs[roleName]; err != nil {
+		return nil, err
+	}
+
+	out := &iam.ListAttachedRolePoliciesOutput{}
+	for _, policyName := range f.policies[roleName] {
+		out.AttachedPolicies = append(out.AttachedPolicies, iamtypes.AttachedPolicy{PolicyName: aws.String(policyName)})
+	}
+
+	return out, nil
+}
+
+func TestGetAllLambdaFunctionsRolePolicies(t *testing.T) {
+	client := &fakeRolePolicyLister{
+		policies: map[string][]string{"shared": {"ReadOnly", "Logs"}},
+		errs:     map[string]error{"broken": &smithy.GenericAPIError{Code: "NoSuchEntity", Message: "the role doesn't exist"}},
+	}
+
+	lambdaFunctionsList := []lambdaFunction{
+		{Name: "first", Region: "us-east-1", IamRole: "arn:aws:iam::111111111111:role/service-role/shared"},
+		{Name: "second", Region: "us-east-1", IamRole: "arn:aws:iam::111111111111:role/service-role/shared"},
+		// the role with the same name in another account is another role
+		{Name: "third", Region: "us-east-1", IamRole: "arn:aws:iam::222222222222:role/shared"},
+		{Name: "fourth", Region: "us-east-1", IamRole: "arn:aws:iam::111111111111:role/broken"},
+		{Name: "fifth", Region: "us-east-1", IamRole: "arn:aws:iam::111111111111:role/broken"},
+		{Name: "no-role", Region: "us-east-1", IamRole: "-"},
+	}
+	for i := range lambdaFunctionsList {
+		lambdaFunctionsList[i].RolePolicies = "-"
+	}
+
+	app := newTestApplication()
+	app.iamClient = client
+	app.getAllLambdaFunctionsRolePolicies(context.Background(), lambdaFunctionsList, app.generateJobs(context.Background(), lambdaFunctionsList), 3)
+
+	if client.calls != 3 {
+		t.Errorf("ListAttachedRolePolicies is called %d times, want once per role ARN", client.calls)
+	}
+
+	want := map[string]string{"first": "ReadOnly;Logs", "second": "ReadOnly;Logs", "third": "ReadOnly;Logs", "fourth": "-", "fifth": "-", "no-role": "-"}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		if lambdaDetails.RolePolicies != want[lambdaDetails.Name] {
+			t.Errorf("role policies of %s = %q, want %q", lambdaDetails.Name, lambdaDetails.RolePolicies, want[lambdaDetails.Name])
+		}
+	}
+
+	// the failed role is shared by two functions, but it's recorded once
+	if runErrors := app.getErrors(); len(runErrors) != 1 {
+		t.Errorf("recorded %d errors, want 1: %+v", len(runErrors), runErrors)
+	}
+}
+
+func TestGetAllLambdaFunctionsRolePoliciesFatalError(t *testing.T) {
+	client := &fakeRolePolicyLister{
+		errs: map[string]error{"role-0": &smithy.GenericAPIError{Code: "ExpiredTokenException", Message: "the security token has expired"}},
+	}
+
+	lambdaFunctionsList := []lambdaFunction{}
+	for _, functionDetail := range functionConfigurations("function", 50) {
+		lambdaDetails := newLambdaFunction(functionDetail, "us-east-1")
+		lambdaDetails.IamRole = "arn:aws:iam::111111111111:role/role-" + lambdaDetails.Name[len("function-"):]
+		lambdaFunctionsList = append(lambdaFunctionsList, lambdaDetails)
+	}
+
+	app := newTestApplication()
+	app.iamClient = client
+	app.getAllLambdaFunctionsRolePolicies(context.Background(), lambdaFunctionsList, app.generateJobs(context.Background(), lambdaFunctionsList), 1)
+
+	// the expired credentials stop listing the remaining roles, so only a few of them are called
+	if client.calls == len(lambdaFunctionsList) || len(app.getErrors()) == 0 {
+		t.Errorf("recorded %d errors after %d calls, want the calls to stop after the fatal error", len(app.getErrors()), client.calls)
+	}
+}
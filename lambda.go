@@ -1,30 +1,486 @@
 package main
 
-import "reflect"
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// defaultEphemeralStorageMB is the default size of the /tmp directory of a Lambda function
+const defaultEphemeralStorageMB = 512
 
 // lambdaFunction contains the details of the lambda function that will be printed
 // `title` tag is the title of the column of the resulting CSV file
+// `json` tag is the key of the field in the resulting JSON and JSONL file
+// New fields are added at the end, so that the existing columns keep their position in the output
 type lambdaFunction struct {
-	Name         string `title:"Function Name"`
-	Region       string `title:"Region"`
-	Arn          string `title:"Function ARN"`
-	Description  string `title:"Function Description"`
-	LastModified string `title:"Last Modified"`
-	IamRole      string `title:"IAM Role"`
-	Runtime      string `title:"Runtime"`
-	LastInvoked  string `title:"Last Invoked"`
+	Name                   string            `title:"Function Name" json:"name"`
+	Region                 string            `title:"Region" json:"region"`
+	Arn                    string            `title:"Function ARN" json:"arn"`
+	Description            string            `title:"Function Description" json:"description"`
+	LastModified           string            `title:"Last Modified" json:"last_modified"`
+	IamRole                string            `title:"IAM Role" json:"iam_role"`
+	Runtime                string            `title:"Runtime" json:"runtime"`
+	LastInvoked            string            `title:"Last Invoked" json:"last_invoked"`
+	MemorySize             int32             `title:"Memory Size (MB)" json:"memory_size"`
+	Timeout                int32             `title:"Timeout (Seconds)" json:"timeout"`
+	Architectures          string            `title:"Architectures" json:"architectures"`
+	Tags                   map[string]string `title:"Tags" json:"tags,omitempty"`
+	PackageType            string            `title:"Package Type" json:"package_type"`
+	ImageUri               string            `title:"Image URI" json:"image_uri"`
+	CodeSize               int64             `title:"Code Size (Bytes)" json:"code_size"`
+	EnvVarCount            int               `title:"Environment Variable Count" json:"env_var_count"`
+	VpcId                  string            `title:"VPC ID" json:"vpc_id"`
+	SubnetCount            int               `title:"Subnet Count" json:"subnet_count"`
+	DeadLetterTarget       string            `title:"Dead Letter Target" json:"dead_letter_target"`
+	OnSuccessDestination   string            `title:"On Success Destination" json:"on_success_destination"`
+	OnFailureDestination   string            `title:"On Failure Destination" json:"on_failure_destination"`
+	ReservedConcurrency    string            `title:"Reserved Concurrency" json:"reserved_concurrency"`
+	ProvisionedConcurrency string            `title:"Provisioned Concurrency" json:"provisioned_concurrency"`
+	EphemeralStorageMB     int32             `title:"Ephemeral Storage (MB)" json:"ephemeral_storage_mb"`
+	TracingMode            string            `title:"Tracing Mode" json:"tracing_mode"`
+	Layers                 string            `title:"Layers" json:"layers"`
+	LayerCount             int               `title:"Layer Count" json:"layer_count"`
+	Handler                string            `title:"Handler" json:"handler"`
+	LogRetentionDays       string            `title:"Log Retention (Days)" json:"log_retention_days"`
+	AccountId              string            `title:"Account ID" json:"account_id"`
+	RuntimeStatus          string            `title:"Runtime Status" json:"runtime_status"`
+	Version                string            `title:"Version" json:"version"`
+	Aliases                string            `title:"Aliases" json:"aliases"`
+	IamRoleName            string            `title:"IAM Role Name" json:"iam_role_name"`
+	RolePolicies           string            `title:"Role Policies" json:"role_policies"`
+	LogFormat              string            `title:"Log Format" json:"log_format"`
+	ApplicationLogLevel    string            `title:"Application Log Level" json:"application_log_level"`
+	SystemLogLevel         string            `title:"System Log Level" json:"system_log_level"`
+	LogGroup               string            `title:"Log Group" json:"log_group"`
+	State                  string            `title:"State" json:"state"`
+	LastUpdateStatus       string            `title:"Last Update Status" json:"last_update_status"`
+	InvokeCount            string            `title:"Invoke Count" json:"invoke_count"`
+	SnapStart              string            `title:"SnapStart" json:"snap_start"`
+	CodeSha256             string            `title:"Code SHA256" json:"code_sha256"`
+	RevisionId             string            `title:"Revision ID" json:"revision_id"`
+	SigningProfile         string            `title:"Signing Profile Version ARN" json:"signing_profile_version_arn"`
+	Profile                string            `title:"Profile" json:"profile"`
+	EstMonthlyUSD          string            `title:"Est. Monthly Cost (USD)" json:"est_monthly_usd"`
+	LastInvokedAge         string            `title:"Last Invoked Age" json:"last_invoked_age"`
+}
+
+// newLambdaFunction creates lambdaFunction from the function configuration returned by the Lambda API.
+// Optional fields that are not returned by the API are replaced with "-", except for the description
+// which is left empty. The last invoke time is "-" until it is resolved, and the invoke count is "-"
+// unless it is taken from the metrics
+func newLambdaFunction(functionDetail types.FunctionConfiguration, region string) lambdaFunction {
+	name := stringValueOrDefault(functionDetail.FunctionName, "-")
+	logFormat, applicationLogLevel, systemLogLevel, logGroup := getLoggingConfig(functionDetail.LoggingConfig, name)
+
+	return lambdaFunction{
+		Name:                   name,
+		Region:                 region,
+		Arn:                    stringValueOrDefault(functionDetail.FunctionArn, "-"),
+		Version:                stringValueOrDefault(functionDetail.Version, latestVersion),
+		Aliases:                "-",
+		Description:            stringValueOrDefault(functionDetail.Description, ""),
+		LastModified:           stringValueOrDefault(functionDetail.LastModified, "-"),
+		State:                  enumValueOrDefault(functionDetail.State, "-"),
+		LastUpdateStatus:       enumValueOrDefault(functionDetail.LastUpdateStatus, "-"),
+		IamRole:                stringValueOrDefault(functionDetail.Role, "-"),
+		IamRoleName:            getRoleName(stringValueOrDefault(functionDetail.Role, "-")),
+		RolePolicies:           "-",
+		Runtime:                string(functionDetail.Runtime),
+		RuntimeStatus:          getRuntimeStatus(string(functionDetail.Runtime)),
+		Handler:                stringValueOrDefault(functionDetail.Handler, "-"),
+		MemorySize:             aws.ToInt32(functionDetail.MemorySize),
+		Timeout:                aws.ToInt32(functionDetail.Timeout),
+		CodeSize:               functionDetail.CodeSize,
+		CodeSha256:             stringValueOrDefault(functionDetail.CodeSha256, "-"),
+		RevisionId:             stringValueOrDefault(functionDetail.RevisionId, "-"),
+		SigningProfile:         stringValueOrDefault(functionDetail.SigningProfileVersionArn, "-"),
+		EphemeralStorageMB:     getEphemeralStorageSize(functionDetail.EphemeralStorage),
+		EnvVarCount:            countEnvVars(functionDetail.Environment),
+		SnapStart:              getSnapStart(functionDetail.SnapStart),
+		TracingMode:            getTracingMode(functionDetail.TracingConfig),
+		LogFormat:              logFormat,
+		ApplicationLogLevel:    applicationLogLevel,
+		SystemLogLevel:         systemLogLevel,
+		LogGroup:               logGroup,
+		Layers:                 joinLayerArns(functionDetail.Layers),
+		LayerCount:             len(functionDetail.Layers),
+		VpcId:                  getVpcId(functionDetail.VpcConfig),
+		SubnetCount:            countSubnets(functionDetail.VpcConfig),
+		DeadLetterTarget:       getDeadLetterTarget(functionDetail.DeadLetterConfig),
+		OnSuccessDestination:   "-",
+		OnFailureDestination:   "-",
+		ReservedConcurrency:    "-",
+		ProvisionedConcurrency: "-",
+		Architectures:          joinArchitectures(functionDetail.Architectures),
+		PackageType:            string(functionDetail.PackageType),
+		ImageUri:               defaultImageUri(functionDetail.PackageType),
+		LastInvoked:            "-",
+		LastInvokedAge:         "-",
+		InvokeCount:            "-",
+		EstMonthlyUSD:          "-",
+	}
+}
+
+// defaultImageUri returns the image URI placeholder before it is resolved using GetFunction.
+// For functions that are not container images (e.g. Zip), the image URI is "-"
+func defaultImageUri(packageType types.PackageType) string {
+	if packageType == types.PackageTypeImage {
+		return ""
+	}
+
+	return "-"
+}
+
+// joinArchitectures joins the instruction set architectures of the function with ";".
+// If the API does not return any architecture, it returns x86_64, which is the AWS default
+func joinArchitectures(architectures []types.Architecture) string {
+	if len(architectures) == 0 {
+		return string(types.ArchitectureX8664)
+	}
+
+	values := []string{}
+	for _, architecture := range architectures {
+		values = append(values, string(architecture))
+	}
+
+	return strings.Join(values, ";")
+}
+
+// getEphemeralStorageSize returns the size of the /tmp directory of the function in MB.
+// If the API does not return it, it returns 512, which is the AWS default
+func getEphemeralStorageSize(ephemeralStorage *types.EphemeralStorage) int32 {
+	if ephemeralStorage == nil || ephemeralStorage.Size == nil {
+		return defaultEphemeralStorageMB
+	}
+
+	return *ephemeralStorage.Size
+}
+
+// countEnvVars returns the number of environment variables of the function.
+// Only the count is returned, so that the values, which may contain secrets, are never printed
+func countEnvVars(environment *types.EnvironmentResponse) int {
+	if environment == nil {
+		return 0
+	}
+
+	return len(environment.Variables)
+}
+
+// getSnapStart returns when SnapStart is applied to the function (PublishedVersions or None),
+// or "-" if the API does not return the SnapStart config
+func getSnapStart(snapStart *types.SnapStartResponse) string {
+	if snapStart == nil || snapStart.ApplyOn == "" {
+		return "-"
+	}
+
+	return string(snapStart.ApplyOn)
+}
+
+// getTracingMode returns the X-Ray tracing mode of the function (Active or PassThrough),
+// or "-" if the API does not return the tracing config
+func getTracingMode(tracingConfig *types.TracingConfigResponse) string {
+	if tracingConfig == nil || tracingConfig.Mode == "" {
+		return "-"
+	}
+
+	return string(tracingConfig.Mode)
+}
+
+// getLoggingConfig returns the log format, the application and system log levels, and the log group of the function.
+// The values that are not returned by the API are replaced with the AWS defaults, which are the Text format
+// and the /aws/lambda/[function name] log group. The log levels only apply to the JSON format,
+// so they are "-" for the Text format and INFO by default for the JSON format
+func getLoggingConfig(loggingConfig *types.LoggingConfig, functionName string) (string, string, string, string) {
+	logFormat := string(types.LogFormatText)
+	applicationLogLevel := "-"
+	systemLogLevel := "-"
+	logGroup := lambdaLogGroupPrefix + functionName
+
+	if loggingConfig == nil {
+		return logFormat, applicationLogLevel, systemLogLevel, logGroup
+	}
+
+	if loggingConfig.LogFormat != "" {
+		logFormat = string(loggingConfig.LogFormat)
+	}
+	if logFormat == string(types.LogFormatJson) {
+		applicationLogLevel = string(types.ApplicationLogLevelInfo)
+		systemLogLevel = string(types.SystemLogLevelInfo)
+		if loggingConfig.ApplicationLogLevel != "" {
+			applicationLogLevel = string(loggingConfig.ApplicationLogLevel)
+		}
+		if loggingConfig.SystemLogLevel != "" {
+			systemLogLevel = string(loggingConfig.SystemLogLevel)
+		}
+	}
+	if loggingConfig.LogGroup != nil && *loggingConfig.LogGroup != "" {
+		logGroup = *loggingConfig.LogGroup
+	}
+
+	return logFormat, applicationLogLevel, systemLogLevel, logGroup
+}
+
+// joinLayerArns joins the ARNs of the layers used by the function with ";".
+// It returns an empty string if the function has no layer
+func joinLayerArns(layers []types.Layer) string {
+	arns := []string{}
+	for _, layer := range layers {
+		if layer.Arn != nil {
+			arns = append(arns, *layer.Arn)
+		}
+	}
+
+	return strings.Join(arns, ";")
+}
+
+// getVpcId returns the ID of the VPC the function is attached to, or "-" if it is not attached to a VPC
+func getVpcId(vpcConfig *types.VpcConfigResponse) string {
+	if vpcConfig == nil || aws.ToString(vpcConfig.VpcId) == "" {
+		return "-"
+	}
+
+	return *vpcConfig.VpcId
+}
+
+// countSubnets returns the number of subnets the function is attached to, or 0 if it is not attached to a VPC
+func countSubnets(vpcConfig *types.VpcConfigResponse) int {
+	if vpcConfig == nil {
+		return 0
+	}
+
+	return len(vpcConfig.SubnetIds)
+}
+
+// getDeadLetterTarget returns the ARN of the dead-letter queue or topic of the function, or "-" if it has none
+func getDeadLetterTarget(deadLetterConfig *types.DeadLetterConfig) string {
+	if deadLetterConfig == nil || aws.ToString(deadLetterConfig.TargetArn) == "" {
+		return "-"
+	}
+
+	return *deadLetterConfig.TargetArn
+}
+
+// getDestinations returns the ARN of the on success and on failure destinations of the function.
+// Missing destinations are returned as "-"
+func getDestinations(destinationConfig *types.DestinationConfig) (string, string) {
+	onSuccess, onFailure := "-", "-"
+	if destinationConfig == nil {
+		return onSuccess, onFailure
+	}
+
+	if destinationConfig.OnSuccess != nil && aws.ToString(destinationConfig.OnSuccess.Destination) != "" {
+		onSuccess = *destinationConfig.OnSuccess.Destination
+	}
+	if destinationConfig.OnFailure != nil && aws.ToString(destinationConfig.OnFailure.Destination) != "" {
+		onFailure = *destinationConfig.OnFailure.Destination
+	}
+
+	return onSuccess, onFailure
 }
 
-// getTitleFields will return a list of strings that is populated by the struct title tag.
+// hasImagePackageType checks whether any of the Lambda functions is deployed as a container image
+func hasImagePackageType(lambdaFunctionsList []lambdaFunction) bool {
+	for _, lambdaDetails := range lambdaFunctionsList {
+		if lambdaDetails.PackageType == string(types.PackageTypeImage) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getTotalCodeSize returns the sum of the deployment package size of all Lambda functions in bytes
+func getTotalCodeSize(lambdaFunctionsList []lambdaFunction) int64 {
+	var total int64
+	for _, lambdaDetails := range lambdaFunctionsList {
+		total += lambdaDetails.CodeSize
+	}
+
+	return total
+}
+
+// formatBytes formats the size in bytes into a human-readable string, e.g. 1.5 MB
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	divisor, exponent := int64(unit), 0
+	for n := size / unit; n >= unit && exponent < 2; n /= unit {
+		divisor *= unit
+		exponent++
+	}
+
+	return fmt.Sprintf("%.1f %s", float64(size)/float64(divisor), []string{"KB", "MB", "GB"}[exponent])
+}
+
+// stringValueOrDefault returns the value of the string pointer, or defaultValue if the pointer is nil
+func stringValueOrDefault(s *string, defaultValue string) string {
+	if s == nil {
+		return defaultValue
+	}
+
+	return *s
+}
+
+// enumValueOrDefault returns the value of the enum, or defaultValue if the API does not return it
+func enumValueOrDefault[T ~string](value T, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+
+	return string(value)
+}
+
+// optionalColumns are the columns that are only filled when their flag is set, keyed by the struct field name.
+// They are only written by default when the flag is set, so that the default output doesn't change,
+// and they can still be chosen with -columns
+var optionalColumns = map[string]func(stg settings) bool{
+	"Tags":                   func(stg settings) bool { return stg.withTags || len(stg.tagFilters) > 0 },
+	"OnSuccessDestination":   func(stg settings) bool { return stg.withDestinations },
+	"OnFailureDestination":   func(stg settings) bool { return stg.withDestinations },
+	"ReservedConcurrency":    func(stg settings) bool { return stg.withConcurrency },
+	"ProvisionedConcurrency": func(stg settings) bool { return stg.withConcurrency },
+	"LogRetentionDays":       func(stg settings) bool { return stg.withLogRetention },
+	"AccountId":              func(stg settings) bool { return stg.withAccountId || stg.profiles != "" },
+	"Version":                func(stg settings) bool { return stg.includeVersions },
+	"Aliases":                func(stg settings) bool { return stg.withAliases },
+	"RolePolicies":           func(stg settings) bool { return stg.withRolePolicies },
+	"InvokeCount":            func(stg settings) bool { return stg.lastInvokeSource == lastInvokeSourceMetrics },
+	"CodeSha256":             func(stg settings) bool { return stg.detailed },
+	"RevisionId":             func(stg settings) bool { return stg.detailed },
+	"SigningProfile":         func(stg settings) bool { return stg.detailed },
+	"Profile":                func(stg settings) bool { return stg.profiles != "" },
+	"EstMonthlyUSD":          func(stg settings) bool { return stg.withCostEstimate },
+	"LastInvokedAge":         func(stg settings) bool { return stg.withLastInvokedAge },
+}
+
+// getDefaultColumns returns the columns that are written when -columns is not chosen, which are all columns
+// except the optional columns which flag is not set
+func getDefaultColumns(stg settings) []string {
+	return slices.DeleteFunc(getColumnNames(), func(column string) bool {
+		enabled, ok := optionalColumns[column]
+		return ok && !enabled(stg)
+	})
+}
+
+// getColumnNames returns the struct field names of lambdaFunction, which are the valid column names
+func getColumnNames() []string {
+	var columnNames []string
+
+	valueType := reflect.TypeOf(lambdaFunction{})
+	for i := range valueType.NumField() {
+		columnNames = append(columnNames, valueType.Field(i).Name)
+	}
+
+	return columnNames
+}
+
+// validateColumns makes sure that all chosen columns are struct field names of lambdaFunction.
+// It returns an error listing the invalid column names and the valid choices
+func validateColumns(columns []string) error {
+	columnNames := getColumnNames()
+
+	invalidColumns := []string{}
+	for _, column := range columns {
+		if !slices.Contains(columnNames, column) {
+			invalidColumns = append(invalidColumns, column)
+		}
+	}
+
+	if len(invalidColumns) > 0 {
+		return fmt.Errorf("invalid columns: %s, valid columns are %s",
+			strings.Join(invalidColumns, ", "), strings.Join(columnNames, ", "))
+	}
+
+	return nil
+}
+
+// getTitleFields will return a list of strings that is populated by the struct title tag
+// of the chosen columns, in the chosen order. If no column is chosen, all struct fields are used.
 // This is done to make sure that if the struct fields change in the future, the title fields are still accurate
-func (l lambdaFunction) getTitleFields() []string {
+func (l lambdaFunction) getTitleFields(columns []string) []string {
 	var titles []string
 
-	value := reflect.ValueOf(l)
-	for i := range value.NumField() {
-		title := value.Type().Field(i).Tag.Get("title")
-		titles = append(titles, title)
+	if len(columns) == 0 {
+		columns = getColumnNames()
+	}
+
+	valueType := reflect.TypeOf(l)
+	for _, column := range columns {
+		field, _ := valueType.FieldByName(column)
+		titles = append(titles, field.Tag.Get("title"))
 	}
 
 	return titles
 }
+
+// getRecordFields will return a list of strings that is populated by the struct field values
+// of the chosen columns, in the same order as the fields returned by getTitleFields. This way each record always
+// lines up with the title row
+func (l lambdaFunction) getRecordFields(columns []string) []string {
+	var record []string
+
+	if len(columns) == 0 {
+		columns = getColumnNames()
+	}
+
+	value := reflect.ValueOf(l)
+	for _, column := range columns {
+		switch fieldValue := value.FieldByName(column).Interface().(type) {
+		case map[string]string:
+			record = append(record, formatTags(fieldValue))
+		default:
+			record = append(record, fmt.Sprint(fieldValue))
+		}
+	}
+
+	return record
+}
+
+// formatTags formats the tags as key1=val1;key2=val2, sorted by the tag key
+func formatTags(tags map[string]string) string {
+	pairs := []string{}
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, tags[key]))
+	}
+
+	return strings.Join(pairs, ";")
+}
+
+// newColumnsType returns a struct type with only the chosen fields of lambdaFunction, in the chosen order and
+// with the same tags, so that the JSON output only has the keys of the chosen columns
+func newColumnsType(columns []string) reflect.Type {
+	lambdaType := reflect.TypeOf(lambdaFunction{})
+
+	fields := make([]reflect.StructField, 0, len(columns))
+	for _, column := range columns {
+		field, _ := lambdaType.FieldByName(column)
+		fields = append(fields, reflect.StructField{
+			Name: field.Name,
+			Type: field.Type,
+			Tag:  field.Tag,
+		})
+	}
+
+	return reflect.StructOf(fields)
+}
+
+// selectColumns copies the fields of l into a new value of columnsType, which is returned by newColumnsType
+func (l lambdaFunction) selectColumns(columnsType reflect.Type) any {
+	value := reflect.ValueOf(l)
+
+	selected := reflect.New(columnsType).Elem()
+	for i := range columnsType.NumField() {
+		selected.Field(i).Set(value.FieldByName(columnsType.Field(i).Name))
+	}
+
+	return selected.Interface()
+}
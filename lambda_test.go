@@ -0,0 +1,409 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+func TestTitleAndRecordFieldsAlign(t *testing.T) {
+	lambdaDetails := newLambdaFunction(types.FunctionConfiguration{
+		FunctionName: aws.String("my-function"),
+		FunctionArn:  aws.String("arn:aws:lambda:us-east-1:123456789012:function:my-function"),
+		Description:  aws.String("my description"),
+		Runtime:      types.RuntimePython312,
+	}, "us-east-1")
+	lambdaDetails.Tags = map[string]string{"team": "payments", "env": "prod"}
+
+	tests := []struct {
+		name        string
+		columns     []string
+		wantTitles  []string
+		wantRecords []string
+	}{
+		{
+			name:        "subset in the chosen order",
+			columns:     []string{"Region", "Name", "Tags"},
+			wantTitles:  []string{"Region", "Function Name", "Tags"},
+			wantRecords: []string{"us-east-1", "my-function", "env=prod;team=payments"},
+		},
+		{
+			name:        "single column",
+			columns:     []string{"Runtime"},
+			wantTitles:  []string{"Runtime"},
+			wantRecords: []string{"python3.12"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			titles := lambdaDetails.getTitleFields(tt.columns)
+			records := lambdaDetails.getRecordFields(tt.columns)
+
+			if !slices.Equal(titles, tt.wantTitles) {
+				t.Errorf("titles = %q, want %q", titles, tt.wantTitles)
+			}
+			if !slices.Equal(records, tt.wantRecords) {
+				t.Errorf("records = %q, want %q", records, tt.wantRecords)
+			}
+		})
+	}
+
+	t.Run("all columns", func(t *testing.T) {
+		titles := lambdaDetails.getTitleFields(nil)
+		records := lambdaDetails.getRecordFields(nil)
+
+		valueType := reflect.TypeOf(lambdaFunction{})
+		if len(titles) != valueType.NumField() || len(records) != valueType.NumField() {
+			t.Fatalf("got %d titles and %d records, want %d of each", len(titles), len(records), valueType.NumField())
+		}
+
+		// each record lines up with the title of the same struct field
+		for i, column := range getColumnNames() {
+			wantTitles := lambdaDetails.getTitleFields([]string{column})
+			wantRecords := lambdaDetails.getRecordFields([]string{column})
+			if titles[i] == "" {
+				t.Errorf("column %q has no title tag", column)
+			}
+			if titles[i] != wantTitles[0] || records[i] != wantRecords[0] {
+				t.Errorf("column %d is %q = %q, want %q = %q", i, titles[i], records[i], wantTitles[0], wantRecords[0])
+			}
+		}
+
+		if titles[1] != "Region" || records[1] != "us-east-1" {
+			t.Errorf("second column is %q = %q, want the region", titles[1], records[1])
+		}
+	})
+}
+
+func TestNewLambdaFunctionNilFields(t *testing.T) {
+	tests := []struct {
+		name           string
+		functionDetail types.FunctionConfiguration
+		want           lambdaFunction
+	}{
+		{
+			name: "nil description",
+			functionDetail: types.FunctionConfiguration{
+				FunctionName: aws.String("my-function"),
+				FunctionArn:  aws.String("arn:aws:lambda:us-east-1:123456789012:function:my-function"),
+				LastModified: aws.String("2024-05-01T12:30:00.000+0000"),
+				Role:         aws.String("arn:aws:iam::123456789012:role/my-role"),
+			},
+			want: lambdaFunction{
+				Name:         "my-function",
+				Arn:          "arn:aws:lambda:us-east-1:123456789012:function:my-function",
+				Description:  "",
+				LastModified: "2024-05-01T12:30:00.000+0000",
+				IamRole:      "arn:aws:iam::123456789012:role/my-role",
+			},
+		},
+		{
+			name:           "no field at all",
+			functionDetail: types.FunctionConfiguration{},
+			want: lambdaFunction{
+				Name:         "-",
+				Arn:          "-",
+				Description:  "",
+				LastModified: "-",
+				IamRole:      "-",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newLambdaFunction(tt.functionDetail, "us-east-1")
+
+			if got.Name != tt.want.Name || got.Arn != tt.want.Arn || got.Description != tt.want.Description ||
+				got.LastModified != tt.want.LastModified || got.IamRole != tt.want.IamRole {
+				t.Errorf("newLambdaFunction() = %+v, want %+v", got, tt.want)
+			}
+			if got.Region != "us-east-1" || got.LastInvoked != "-" {
+				t.Errorf("region = %q and last invoked = %q, want us-east-1 and -", got.Region, got.LastInvoked)
+			}
+
+			// every column can be written without any optional field
+			if records := got.getRecordFields(nil); len(records) != len(getColumnNames()) {
+				t.Errorf("got %d record fields, want %d", len(records), len(getColumnNames()))
+			}
+		})
+	}
+}
+
+func TestCountEnvVars(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment *types.EnvironmentResponse
+		want        int
+	}{
+		{name: "nil environment", environment: nil, want: 0},
+		{name: "no variables", environment: &types.EnvironmentResponse{}, want: 0},
+		{
+			name:        "populated environment",
+			environment: &types.EnvironmentResponse{Variables: map[string]string{"STAGE": "prod", "SECRET": "value"}},
+			want:        2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countEnvVars(tt.environment); got != tt.want {
+				t.Errorf("countEnvVars() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVpcConfig(t *testing.T) {
+	tests := []struct {
+		name            string
+		vpcConfig       *types.VpcConfigResponse
+		wantVpcId       string
+		wantSubnetCount int
+	}{
+		{name: "not attached to a VPC", vpcConfig: nil, wantVpcId: "-", wantSubnetCount: 0},
+		{name: "empty VPC config", vpcConfig: &types.VpcConfigResponse{VpcId: aws.String("")}, wantVpcId: "-", wantSubnetCount: 0},
+		{
+			name: "attached to a VPC",
+			vpcConfig: &types.VpcConfigResponse{
+				VpcId:     aws.String("vpc-0123456789abcdef0"),
+				SubnetIds: []string{"subnet-1", "subnet-2", "subnet-3"},
+			},
+			wantVpcId:       "vpc-0123456789abcdef0",
+			wantSubnetCount: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getVpcId(tt.vpcConfig); got != tt.wantVpcId {
+				t.Errorf("getVpcId() = %q, want %q", got, tt.wantVpcId)
+			}
+			if got := countSubnets(tt.vpcConfig); got != tt.wantSubnetCount {
+				t.Errorf("countSubnets() = %d, want %d", got, tt.wantSubnetCount)
+			}
+		})
+	}
+}
+
+func TestGetEphemeralStorageSize(t *testing.T) {
+	tests := []struct {
+		name             string
+		ephemeralStorage *types.EphemeralStorage
+		want             int32
+	}{
+		{name: "nil ephemeral storage", ephemeralStorage: nil, want: defaultEphemeralStorageMB},
+		{name: "nil size", ephemeralStorage: &types.EphemeralStorage{}, want: defaultEphemeralStorageMB},
+		{name: "configured size", ephemeralStorage: &types.EphemeralStorage{Size: aws.Int32(10240)}, want: 10240},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getEphemeralStorageSize(tt.ephemeralStorage); got != tt.want {
+				t.Errorf("getEphemeralStorageSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTracingMode(t *testing.T) {
+	tests := []struct {
+		name          string
+		tracingConfig *types.TracingConfigResponse
+		want          string
+	}{
+		{name: "nil tracing config", tracingConfig: nil, want: "-"},
+		{name: "empty mode", tracingConfig: &types.TracingConfigResponse{}, want: "-"},
+		{name: "active", tracingConfig: &types.TracingConfigResponse{Mode: types.TracingModeActive}, want: "Active"},
+		{name: "pass through", tracingConfig: &types.TracingConfigResponse{Mode: types.TracingModePassThrough}, want: "PassThrough"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getTracingMode(tt.tracingConfig); got != tt.want {
+				t.Errorf("getTracingMode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayers(t *testing.T) {
+	tests := []struct {
+		name       string
+		layers     []types.Layer
+		wantLayers string
+	}{
+		{name: "no layer", layers: nil, wantLayers: ""},
+		{
+			name:       "one layer",
+			layers:     []types.Layer{{Arn: aws.String("arn:aws:lambda:us-east-1:123456789012:layer:shared:3")}},
+			wantLayers: "arn:aws:lambda:us-east-1:123456789012:layer:shared:3",
+		},
+		{
+			name: "multiple layers",
+			layers: []types.Layer{
+				{Arn: aws.String("arn:aws:lambda:us-east-1:123456789012:layer:shared:3")},
+				{Arn: aws.String("arn:aws:lambda:us-east-1:123456789012:layer:extension:1")},
+			},
+			wantLayers: "arn:aws:lambda:us-east-1:123456789012:layer:shared:3;arn:aws:lambda:us-east-1:123456789012:layer:extension:1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lambdaDetails := newLambdaFunction(types.FunctionConfiguration{Layers: tt.layers}, "us-east-1")
+
+			if lambdaDetails.Layers != tt.wantLayers {
+				t.Errorf("layers = %q, want %q", lambdaDetails.Layers, tt.wantLayers)
+			}
+			if lambdaDetails.LayerCount != len(tt.layers) {
+				t.Errorf("layer count = %d, want %d", lambdaDetails.LayerCount, len(tt.layers))
+			}
+		})
+	}
+}
+
+func TestGetLoggingConfig(t *testing.T) {
+	tests := []struct {
+		name                    string
+		loggingConfig           *types.LoggingConfig
+		wantLogFormat           string
+		wantApplicationLogLevel string
+		wantSystemLogLevel      string
+		wantLogGroup            string
+	}{
+		{
+			name:                    "nil logging config",
+			loggingConfig:           nil,
+			wantLogFormat:           "Text",
+			wantApplicationLogLevel: "-",
+			wantSystemLogLevel:      "-",
+			wantLogGroup:            "/aws/lambda/my-function",
+		},
+		{
+			name:                    "JSON format with the default log levels",
+			loggingConfig:           &types.LoggingConfig{LogFormat: types.LogFormatJson},
+			wantLogFormat:           "JSON",
+			wantApplicationLogLevel: "INFO",
+			wantSystemLogLevel:      "INFO",
+			wantLogGroup:            "/aws/lambda/my-function",
+		},
+		{
+			name: "JSON format with a custom log group",
+			loggingConfig: &types.LoggingConfig{
+				LogFormat:           types.LogFormatJson,
+				ApplicationLogLevel: types.ApplicationLogLevelDebug,
+				SystemLogLevel:      types.SystemLogLevelWarn,
+				LogGroup:            aws.String("/shared/logs"),
+			},
+			wantLogFormat:           "JSON",
+			wantApplicationLogLevel: "DEBUG",
+			wantSystemLogLevel:      "WARN",
+			wantLogGroup:            "/shared/logs",
+		},
+		{
+			name:                    "log levels are ignored with the Text format",
+			loggingConfig:           &types.LoggingConfig{LogFormat: types.LogFormatText, ApplicationLogLevel: types.ApplicationLogLevelDebug},
+			wantLogFormat:           "Text",
+			wantApplicationLogLevel: "-",
+			wantSystemLogLevel:      "-",
+			wantLogGroup:            "/aws/lambda/my-function",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logFormat, applicationLogLevel, systemLogLevel, logGroup := getLoggingConfig(tt.loggingConfig, "my-function")
+
+			if logFormat != tt.wantLogFormat || applicationLogLevel != tt.wantApplicationLogLevel ||
+				systemLogLevel != tt.wantSystemLogLevel || logGroup != tt.wantLogGroup {
+				t.Errorf("getLoggingConfig() = %q, %q, %q, %q, want %q, %q, %q, %q",
+					logFormat, applicationLogLevel, systemLogLevel, logGroup,
+					tt.wantLogFormat, tt.wantApplicationLogLevel, tt.wantSystemLogLevel, tt.wantLogGroup)
+			}
+		})
+	}
+}
+
+func TestStateColumns(t *testing.T) {
+	tests := []struct {
+		name                 string
+		functionDetail       types.FunctionConfiguration
+		wantState            string
+		wantLastUpdateStatus string
+	}{
+		{
+			name:                 "not returned by the API",
+			functionDetail:       types.FunctionConfiguration{},
+			wantState:            "-",
+			wantLastUpdateStatus: "-",
+		},
+		{
+			name:                 "populated",
+			functionDetail:       types.FunctionConfiguration{State: types.StateActive, LastUpdateStatus: types.LastUpdateStatusInProgress},
+			wantState:            "Active",
+			wantLastUpdateStatus: "InProgress",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lambdaDetails := newLambdaFunction(tt.functionDetail, "us-east-1")
+
+			if lambdaDetails.State != tt.wantState || lambdaDetails.LastUpdateStatus != tt.wantLastUpdateStatus {
+				t.Errorf("state = %q and last update status = %q, want %q and %q",
+					lambdaDetails.State, lambdaDetails.LastUpdateStatus, tt.wantState, tt.wantLastUpdateStatus)
+			}
+		})
+	}
+}
+
+func TestGetSnapStart(t *testing.T) {
+	tests := []struct {
+		name      string
+		snapStart *types.SnapStartResponse
+		want      string
+	}{
+		{name: "nil snap start", snapStart: nil, want: "-"},
+		{name: "not enabled", snapStart: &types.SnapStartResponse{ApplyOn: types.SnapStartApplyOnNone}, want: "None"},
+		{name: "enabled", snapStart: &types.SnapStartResponse{ApplyOn: types.SnapStartApplyOnPublishedVersions, OptimizationStatus: types.SnapStartOptimizationStatusOn}, want: "PublishedVersions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getSnapStart(tt.snapStart); got != tt.want {
+				t.Errorf("getSnapStart() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{size: 0, want: "0 B"},
+		{size: 1023, want: "1023 B"},
+		{size: 1024, want: "1.0 KB"},
+		{size: 1536, want: "1.5 KB"},
+		{size: 5 * 1024 * 1024, want: "5.0 MB"},
+		{size: 3 * 1024 * 1024 * 1024 / 2, want: "1.5 GB"},
+		{size: 2048 * 1024 * 1024 * 1024, want: "2048.0 GB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBytes(tt.size); got != tt.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+
+	total := getTotalCodeSize([]lambdaFunction{{CodeSize: 1024}, {CodeSize: 512}, {}})
+	if total != 1536 {
+		t.Errorf("getTotalCodeSize() = %d, want 1536", total)
+	}
+}
@@ -2,126 +2,793 @@ package main
 
 import (
 	"context"
-	"encoding/csv"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math/rand/v2"
 	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/aws/ratelimit"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"golang.org/x/time/rate"
 )
 
 // settings stores the user input arguments when running the program
 type settings struct {
-	debug          bool
-	awsProfileName string
-	getAllRegions  bool
-	outputFileName string
-	maxWorkers     int
+	configFile   string
+	printVersion bool
+
+	debug               bool
+	logFormat           string
+	logFile             string
+	awsProfileName      string
+	useEnvCreds         bool
+	profiles            string
+	getAllRegions       bool
+	regions             string
+	excludeRegions      string
+	excludeOptInRegions bool
+	outputFileName      string
+	appendOutput        bool
+	gzipOutput          bool
+	maxWorkers          int
+	listWorkers         int
+	listPageSize        int
+	logsWorkers         int
+	adaptiveWorkers     bool
+	rateLimit           float64
+	outputFormat        string
+	columns             string
+	sortBy              string
+	sortDesc            bool
+	truncateDesc        int
+	runtimes            stringListFlag
+	tagFilters          stringListFlag
+	nameFilter          string
+	withTags            bool
+	withDestinations    bool
+	withConcurrency     bool
+	withCostEstimate    bool
+	withLastInvokedAge  bool
+	withLogRetention    bool
+	detailed            bool
+	timeout             time.Duration
+	maxRetries          int
+	useFIPS             bool
+	useDualStack        bool
+	endpointURL         string
+	s3Region            string
+
+	assumeRoleArn   string
+	externalID      string
+	roleSessionName string
+	mfaSerial       string
+	mfaToken        string
+
+	lastInvokeSource    string
+	skipLastInvoke      bool
+	metricsLookbackDays int
+	lookbackDays        int
+	maxAgeDays          int
+	includeNeverInvoked bool
+	stream              bool
+	prefixAccountId     bool
+	withAccountId       bool
+	onlyDeprecated      bool
+	modifiedAfter       string
+	sample              int
+	seed                uint64
+	timeFormat          string
+	utc                 bool
+	formatLastModified  bool
+	includeVersions     bool
+	withAliases         bool
+	withRolePolicies    bool
+	delimiter           string
+	noHeader            bool
+	functionsFile       string
+	diffAgainst         string
+	diffOutputFileName  string
+	diffLastInvoked     bool
+	failOnAccessDenied  bool
+	fatalErrorCodes     string
+	strict              bool
+	findOrphanedGroups  bool
+	progressInterval    time.Duration
+	dryRun              bool
+	quiet               bool
+	printRegionStats    bool
+
+	emitRunMetrics      bool
+	runMetricsNamespace string
+	runMetricsRegion    string
+}
+
+// getListWorkers returns the maximum number of regions listed at the same time, which defaults to -max-workers
+func (stg settings) getListWorkers() int {
+	if stg.listWorkers > 0 {
+		return stg.listWorkers
+	}
+
+	return stg.maxWorkers
+}
+
+// getLogsWorkers returns the number of workers getting the last invoke time, which defaults to -max-workers
+func (stg settings) getLogsWorkers() int {
+	if stg.logsWorkers > 0 {
+		return stg.logsWorkers
+	}
+
+	return stg.maxWorkers
+}
+
+// getInvocationsLookbackDays returns the number of days of the Invocations metric used for the last invoke time
+// and the invoke count, which is -lookback-days when it is set so that the count covers the same window as the
+// inactive label, and -metrics-lookback-days otherwise
+func (stg settings) getInvocationsLookbackDays() int {
+	if stg.lookbackDays > 0 {
+		return stg.lookbackDays
+	}
+
+	return stg.metricsLookbackDays
+}
+
+// stringListFlag is a flag.Value that collects the values of a flag that can be passed multiple times
+type stringListFlag []string
+
+func (s *stringListFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringListFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
 }
 
 // application stores main program global dependencies
 type application struct {
 	logger        *zap.SugaredLogger
 	cfg           *aws.Config
-	ec2Client     *ec2.Client
-	lambdaClients []*lambda.Client
+	stg           settings
+	ec2Client     regionDescriber
+	iamClient     rolePolicyLister
+	accountId     string
+	profileName   string
+	regions       []string
+	lambdaClients map[string]lambdaAPI
+	cwLogsClients map[string]cwLogsAPI
+	cwClients     map[string]metricDataGetter
+
+	// apiCallCount counts all AWS API call attempts
+	apiCallCount atomic.Int64
+	// phaseDurations are the durations of the phases of the run, in order
+	phaseDurations []phaseDuration
+
+	// rateLimiters limit the rate of the last invoke API calls per region, keyed by region name
+	rateLimiters map[string]*rate.Limiter
+
+	// throttleCount counts the throttled AWS API call attempts
+	throttleCount atomic.Int64
+	// workerConcurrency limits the number of last invoke workers calling the API at the same time.
+	// It is only set with -adaptive-workers
+	workerConcurrency *adaptiveConcurrency
+
+	// lastInvokeProgress counts the functions whose last invoke time has been processed
+	lastInvokeProgress atomic.Int64
+
+	// lastInvokeResults receives the index of each function whose last invoke time has been processed.
+	// It is only set in streaming mode
+	lastInvokeResults chan<- int
+
+	// regionStats accumulates the stats of each region, which are printed with -region-stats
+	regionStats regionStatsCollector
+
+	// runErrors holds the errors encountered during the run, guarded by runErrorsMu
+	runErrorsMu sync.Mutex
+	runErrors   []runError
 }
 
 func main() {
 	var stg settings
 	flag.BoolVar(&stg.debug, "debug", false, "Debug mode. Shows debug logs")
-	flag.StringVar(&stg.awsProfileName, "aws-profile", "default", "AWS Profile Name")
+	flag.StringVar(&stg.logFormat, "log-format", logFormatConsole, "The format of the logs (console or json)")
+	flag.StringVar(&stg.logFile, "log-file", "", "Also append the logs to this file. The file is created if it doesn't exist")
+	flag.StringVar(&stg.awsProfileName, "aws-profile", "default", "AWS Profile Name. If empty, the default credential chain (environment variables, ECS or EC2 role) is used")
+	flag.StringVar(&stg.profiles, "profiles", "", "Comma-separated list of AWS profiles to scan in one run, e.g. dev,prod. The results are merged into one output with the Profile column, and -aws-profile is ignored")
+	flag.BoolVar(&stg.useEnvCreds, "use-env-credentials", false, "Ignore -aws-profile and use the default credential chain (environment variables, ECS or EC2 role)")
 	flag.BoolVar(&stg.getAllRegions, "all-regions", false, "Whether to get data from all AWS Regions")
-	flag.StringVar(&stg.outputFileName, "output-file-name", "", "The name of the output file. If not provided, the resulting file name will be [timestamp].csv")
+	flag.StringVar(&stg.excludeRegions, "exclude-regions", "", "Comma-separated list of AWS Regions to skip when -all-regions is set, e.g. us-gov-west-1,ap-east-1")
+	flag.BoolVar(&stg.excludeOptInRegions, "exclude-opt-in-regions", false, "Skip the opt-in regions, e.g. ap-east-1 or me-south-1, when -all-regions is set, even if the account has opted in to them")
+	flag.StringVar(&stg.regions, "regions", "", "Comma-separated list of AWS Regions to get data from, e.g. us-east-1,eu-west-1. Takes precedence over -all-regions")
+	flag.StringVar(&stg.outputFileName, "output-file-name", "", "The name of the output file. If not provided, the resulting file name will be [timestamp].[output-format]. If it starts with s3://, the output is uploaded to S3. If it is -, the output is written to stdout")
+	flag.BoolVar(&stg.appendOutput, "append", false, "Append to the output file instead of overwriting it. The title row is only written if the file is new or empty. Not supported for json, html, and xlsx output and S3")
+	flag.BoolVar(&stg.gzipOutput, "gzip", false, "Compress the output with gzip. It is also enabled when the output file name ends with .gz")
 	flag.IntVar(&stg.maxWorkers, "max-workers", 10, "Maximum number of workers")
+	flag.IntVar(&stg.listWorkers, "list-workers", 0, "Maximum number of regions listed at the same time. If not provided, -max-workers is used")
+	flag.IntVar(&stg.listPageSize, "list-page-size", maxListPageSize, "The maximum number of functions returned by each ListFunctions call, between 1 and 10000")
+	flag.IntVar(&stg.logsWorkers, "logs-workers", 0, "Maximum number of workers getting the last invoke time from CloudWatch. If not provided, -max-workers is used")
+	flag.BoolVar(&stg.adaptiveWorkers, "adaptive-workers", false, "When getting the last invoke time, start with a few workers and add more while the CloudWatch calls are not throttled, halving them when they are. The number of workers never goes above -logs-workers")
+	flag.Float64Var(&stg.rateLimit, "rate-limit", 0, "Maximum number of CloudWatch requests per second per region when getting the last invoke time, so that the workers don't hit the API rate limits. If not provided, the requests are not limited")
+	flag.StringVar(&stg.outputFormat, "output-format", outputFormatCSV, "The format of the output file (csv, json, jsonl, markdown, html, xlsx, or sqlite)")
+	flag.StringVar(&stg.columns, "columns", "", "Comma-separated list of columns to write in the CSV, markdown, and html output, in order, e.g. Name,Region,Runtime,LastInvoked. If not provided, all columns are written, except the ones filled by a flag that is not set, e.g. Tags without -with-tags")
+	flag.StringVar(&stg.sortBy, "sort-by", "", "Sort the output by this column (Name, Region, LastModified, LastInvoked, or CodeSize). If not provided, the output is sorted by region then name")
+	flag.BoolVar(&stg.sortDesc, "sort-desc", false, "Sort the output in descending order when -sort-by is set")
+	flag.IntVar(&stg.truncateDesc, "truncate-description", 0, "Replace newlines in the description with spaces and cap it at N characters in the CSV and markdown output. The JSON output keeps the raw description. If not provided, the description is not changed")
+	flag.Var(&stg.tagFilters, "tag-filter", "Only list functions with this tag, as key=value. Can be passed multiple times, in which case the functions must have all the tags. The tags are written in the output as with -with-tags")
+	flag.Var(&stg.runtimes, "runtime", "Only list functions with this runtime, e.g. python3.9. Can be passed multiple times. If not provided, functions with all runtimes are listed")
+	flag.StringVar(&stg.nameFilter, "name-filter", "", "Only list functions whose name matches this regular expression, e.g. ^prod-.*-worker$")
+	flag.BoolVar(&stg.withTags, "with-tags", false, "Whether to also get the tags of each function. This makes one additional API call per function")
+	flag.DurationVar(&stg.timeout, "timeout", 5*time.Minute, "Maximum duration of the whole run. When it is reached, the results gathered so far are written to the output")
+	flag.IntVar(&stg.maxRetries, "max-retries", 5, "Maximum number of retries with exponential backoff when an AWS API call fails with a retryable error, e.g. throttling")
+	flag.BoolVar(&stg.useFIPS, "use-fips", false, "Use the FIPS endpoints of all AWS service clients. Only the US, Canada, and GovCloud regions are supported")
+	flag.BoolVar(&stg.useDualStack, "use-dualstack", false, "Use the dual-stack (IPv4 and IPv6) endpoints of Lambda, CloudWatch Logs, CloudWatch, and EC2, e.g. in IPv6-only networks")
+	flag.StringVar(&stg.endpointURL, "endpoint-url", "", "Custom endpoint URL of Lambda, CloudWatch Logs, CloudWatch, EC2, and STS, e.g. http://localhost:4566 for LocalStack. The region settings still apply")
+	flag.StringVar(&stg.s3Region, "s3-region", "", "The region of the S3 bucket when the output file name is an S3 URI. If not provided, the region is detected automatically")
+	flag.StringVar(&stg.assumeRoleArn, "assume-role-arn", "", "ARN of the IAM role to assume using the credentials of the AWS profile, e.g. for cross-account audits")
+	flag.StringVar(&stg.externalID, "external-id", "", "External ID used when assuming the role specified by -assume-role-arn")
+	flag.StringVar(&stg.roleSessionName, "role-session-name", defaultRoleSessionName, "Session name used when assuming the role specified by -assume-role-arn")
+	flag.StringVar(&stg.mfaSerial, "mfa-serial", "", "ARN of the MFA device used when assuming the role specified by -assume-role-arn, or when assuming the role of the AWS profile. It overrides the mfa_serial of the profile")
+	flag.StringVar(&stg.mfaToken, "mfa-token", "", "The MFA token code. If not provided and the role requires MFA, it is prompted")
+	flag.BoolVar(&stg.skipLastInvoke, "skip-last-invoke", false, "Don't get the last invoke time, e.g. without CloudWatch permissions. The Last Invoked column is written as n/a")
+	flag.StringVar(&stg.lastInvokeSource, "last-invoke-source", lastInvokeSourceLogs, "Where to get the last invoke time from. logs uses the latest CloudWatch log stream, metrics uses the CloudWatch Invocations metric")
+	flag.IntVar(&stg.metricsLookbackDays, "metrics-lookback-days", 30, "Number of days to look back for invocations when -with-cost-estimate is set, or when -last-invoke-source=metrics is set without -lookback-days")
+	flag.IntVar(&stg.lookbackDays, "lookback-days", 0, "Report functions which were last invoked more than N days ago as \"inactive (>N days)\" in the output instead of the exact last invoke time. The filters and the sorting still use the exact time. If not provided, the exact time is always reported")
+	flag.IntVar(&stg.maxAgeDays, "max-age-days", 0, "Only list stale functions, which are the functions not invoked in the last N days. Never invoked functions are considered stale. If not provided, all functions are listed")
+	flag.DurationVar(&stg.progressInterval, "progress-interval", 5*time.Second, "How often to log the progress of getting the last invoke time. Set to 0 to disable it. It is also disabled when the output is not a terminal")
+	flag.BoolVar(&stg.dryRun, "dry-run", false, "Only print the number of functions per region, without getting the last invoke time and writing the output file")
+	flag.BoolVar(&stg.withDestinations, "with-destinations", false, "Whether to also get the on success and on failure destinations of each function. This makes one additional API call per function")
+	flag.BoolVar(&stg.quiet, "quiet", false, "Quiet mode. Only error logs are written to stderr, and a single JSON object summarizing the run is printed to stdout at the end")
+	flag.BoolVar(&stg.printRegionStats, "region-stats", false, "At the end of the run, print the number of functions, throttled API calls, and errors, and the average time since the last invocation of each region to stderr")
+	flag.BoolVar(&stg.emitRunMetrics, "emit-run-metrics", false, "At the end of the run, put the number of scanned functions, the number of errors, and the duration as custom CloudWatch metrics")
+	flag.StringVar(&stg.runMetricsNamespace, "run-metrics-namespace", defaultRunMetricsNamespace, "The CloudWatch namespace of the run metrics")
+	flag.StringVar(&stg.runMetricsRegion, "run-metrics-region", "", "The region where the run metrics are put. If not provided, the default region is used")
+	flag.BoolVar(&stg.withLastInvokedAge, "with-last-invoked-age", false, "Whether to also write the time since the last invocation of each function in the Last Invoked Age column, e.g. 12d, 3h, or never")
+	flag.BoolVar(&stg.withCostEstimate, "with-cost-estimate", false, "Whether to also estimate the monthly cost of each function from its CloudWatch Invocations and Duration metrics over -metrics-lookback-days. This makes one additional API call per function")
+	flag.BoolVar(&stg.withConcurrency, "with-concurrency", false, "Whether to also get the reserved and provisioned concurrency of each function. This makes two additional API calls per function")
+	flag.BoolVar(&stg.withLogRetention, "with-log-retention", false, "Whether to also get the retention of the CloudWatch log group of each function. This makes one additional API call per function")
+	flag.BoolVar(&stg.detailed, "detailed", false, "Whether to also get the code SHA256, the revision ID, the signing profile, the state, and the last update status of each function with GetFunction. This makes one additional API call per function")
+	flag.BoolVar(&stg.includeNeverInvoked, "include-never-invoked", true, "Whether to include the functions that were never invoked. Set to false to only list functions with a last invoke time")
+	flag.BoolVar(&stg.stream, "stream", false, "Write each function to the output as soon as its last invoke time is resolved, instead of after all functions. Only supported with the jsonl output format, and can't be used with -sort-by and the -with-* flags. The image URI of image functions is not resolved")
+	flag.BoolVar(&stg.withAccountId, "with-account-id", false, "Whether to also write the ID of the AWS account of each function, which is taken once from STS. It is always written with -profiles")
+	flag.BoolVar(&stg.prefixAccountId, "prefix-account-id", false, "Prefix the generated output file name with the account ID, e.g. 123456789012-1744990200.csv")
+	flag.BoolVar(&stg.onlyDeprecated, "only-deprecated", false, "Only list functions with a runtime that is deprecated or will be deprecated soon by AWS")
+	flag.StringVar(&stg.modifiedAfter, "modified-after", "", "Only list functions last modified after this date, in RFC3339 (e.g. 2025-04-18T15:30:00Z) or YYYY-MM-DD format")
+	flag.IntVar(&stg.sample, "sample", 0, "Only process N functions picked at random after listing and filtering, e.g. to check the output quickly before a full scan")
+	flag.Uint64Var(&stg.seed, "seed", 0, "The seed used to pick the functions of -sample, so that the same functions are picked again. If not provided, a random seed is used")
+	flag.StringVar(&stg.timeFormat, "time-format", timeFormatRFC3339, "The format of the last invoke time in the output. rfc3339 (e.g. 2025-04-18T15:30:00+07:00), epoch (seconds since the Unix epoch), or a Go time layout (e.g. \"2006-01-02 15:04\")")
+	flag.BoolVar(&stg.utc, "utc", false, "Write the last invoke time in UTC instead of the local timezone")
+	flag.BoolVar(&stg.formatLastModified, "format-last-modified", false, "Write the last modified time in the same format and timezone as the last invoke time, instead of the format returned by the Lambda API")
+	flag.BoolVar(&stg.includeVersions, "include-versions", false, "Also list the published versions of each function, one row per version. The $LATEST row has $LATEST as the version")
+	flag.BoolVar(&stg.withAliases, "with-aliases", false, "Whether to also get the aliases pointing to each version when -include-versions is set. This makes one additional API call per function")
+	flag.BoolVar(&stg.withRolePolicies, "with-role-policies", false, "Whether to also get the managed policies attached to the IAM role of each function. This makes one additional API call per role")
+	flag.StringVar(&stg.delimiter, "delimiter", ",", "The field delimiter of the CSV output. It must be a single character. Use \\t for tab-separated values")
+	flag.BoolVar(&stg.noHeader, "no-header", false, "Don't write the title row of the CSV output, e.g. for a parser that expects no header")
+	flag.StringVar(&stg.functionsFile, "functions-file", "", "File with one functionName,region pair per line. Only these functions are listed, instead of all functions of the regions. If the region is omitted, the default region is used")
+	flag.StringVar(&stg.diffAgainst, "diff-against", "", "Compare the result against a CSV file generated by a previous run, and write the added, removed, and changed functions to a diff report")
+	flag.StringVar(&stg.diffOutputFileName, "diff-output-file-name", "", "The name of the diff report file. If not provided, the file name will be [timestamp]-diff.csv. If it is -, the diff report is written to stdout")
+	flag.BoolVar(&stg.diffLastInvoked, "diff-include-last-invoked", false, "Also compare the last invoke time in the diff report")
+	flag.BoolVar(&stg.findOrphanedGroups, "find-orphaned-log-groups", false, "Instead of listing the functions, list the /aws/lambda/ log groups whose function no longer exists, with their stored bytes. Supported with csv, json, and jsonl output")
+	flag.BoolVar(&stg.strict, "strict", false, "Exit with non-zero code if no function is found after filtering, which is often caused by a wrong profile or region. By default, only a warning is logged")
+	flag.StringVar(&stg.fatalErrorCodes, "fatal-error-codes", defaultFatalErrorCodes, "Comma-separated list of AWS error codes that stop getting the last invoke time of the remaining functions, e.g. invalid or expired credentials. Set to empty to never stop")
+	flag.BoolVar(&stg.failOnAccessDenied, "fail-on-access-denied", false, "Stop the run if listing the functions of a region is denied. By default, the region is skipped and the denial is reported in the error summary")
+	flag.BoolVar(&stg.printVersion, "version", false, "Print the version, the commit, and the build date, then exit")
+	flag.StringVar(&stg.configFile, "config-file", "", "YAML file which sets the flags by their name, e.g. max-workers: 20. The flags passed on the command line override the values in the file")
 	flag.Parse()
 
-	logger := createLogger(stg.debug)
+	if stg.printVersion {
+		fmt.Println(getBuildInfo())
+		os.Exit(0)
+	}
+
+	// the config file is loaded before creating the logger, since it can change the logging settings
+	var configErr error
+	if stg.configFile != "" {
+		configErr = loadConfigFile(flag.CommandLine, stg.configFile)
+	}
+
+	startTime := time.Now()
+
+	logger, err := createLogger(stg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error when creating the logger: %v\n", err)
+		os.Exit(2)
+	}
 	defer logger.Sync()
 
-	logger.Debugf("loading config from aws profile named %q", stg.awsProfileName)
-	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithSharedConfigProfile(stg.awsProfileName))
+	if configErr != nil {
+		logger.Fatalw("error when loading the config file",
+			zap.String("config_file", stg.configFile),
+			zap.Error(configErr),
+		)
+	}
+
+	err = validateOutputFormat(stg.outputFormat)
 	if err != nil {
-		logger.Fatalw("error when loading aws profile",
-			zap.String("profile_name", stg.awsProfileName),
+		logger.Fatalw("invalid output format",
 			zap.Error(err),
 		)
 	}
 
-	app, err := initializeApplication(logger, cfg, stg.getAllRegions)
+	outOpts := outputOptions{
+		format:              stg.outputFormat,
+		columns:             parseCommaSeparatedList(stg.columns),
+		gzip:                stg.gzipOutput || strings.HasSuffix(stg.outputFileName, gzipFileExtension),
+		truncateDescription: stg.truncateDesc,
+		skipHeader:          stg.noHeader,
+	}
+	outOpts.delimiter, err = parseDelimiter(stg.delimiter)
 	if err != nil {
-		logger.Fatalw("error when initializing application struct",
+		logger.Fatalw("invalid delimiter",
 			zap.Error(err),
 		)
 	}
 
-	lambdaFunctionsList, err := app.getAllLambdaFunctionsDetails()
+	err = validateColumns(outOpts.columns)
 	if err != nil {
-		logger.Fatalw("error when listing lambda function details",
+		logger.Fatalw("invalid columns",
 			zap.Error(err),
 		)
 	}
+	if len(outOpts.columns) == 0 {
+		outOpts.columns = getDefaultColumns(stg)
+	}
+	outOpts.jsonColumns = getDefaultColumns(stg)
 
-	jobs := app.generateLastInvokeTimeQueryJob(lambdaFunctionsList, stg.maxWorkers)
-	app.getAllLambdaFunctionsLastInvokeTime(lambdaFunctionsList, jobs, stg.maxWorkers)
+	if stg.detailed {
+		logger.Warn("-detailed makes one additional GetFunction call per function, which makes the run slower and may count against the Lambda API rate limits on large accounts")
+	}
+
+	err = validateListPageSize(stg.listPageSize)
+	if err != nil {
+		logger.Fatalw("invalid list page size",
+			zap.Error(err),
+		)
+	}
 
-	fileName := getFileName(stg.outputFileName)
-	logger.Infof("writing the output to %q", fileName)
-	f, err := os.Create(fileName)
+	err = validateSortBy(stg.sortBy)
 	if err != nil {
-		logger.Errorw("error when creating a file",
+		logger.Fatalw("invalid sort column",
 			zap.Error(err),
 		)
 	}
-	defer f.Close()
 
-	w := csv.NewWriter(f)
-	defer w.Flush()
+	if stg.appendOutput && (stg.outputFormat == outputFormatJSON || stg.outputFormat == outputFormatHTML || stg.outputFormat == outputFormatXLSX || isS3URI(stg.outputFileName) || stg.outputFileName == stdoutFileName) {
+		logger.Fatal("-append can't be used with json, html, or xlsx output format, S3 output, or stdout output")
+	}
+
+	if stg.noHeader && stg.outputFormat != outputFormatCSV {
+		logger.Fatal("-no-header can only be used with csv output format")
+	}
+
+	if stg.outputFormat == outputFormatSQLite && (isS3URI(stg.outputFileName) || stg.outputFileName == stdoutFileName || outOpts.gzip) {
+		logger.Fatal("sqlite output format can't be used with S3 output, stdout output, or gzip")
+	}
+
+	var previousList []lambdaFunction
+	var previousColumns []string
+	if stg.diffAgainst != "" {
+		if stg.stream || stg.findOrphanedGroups {
+			logger.Fatal("-diff-against can't be used with -stream or -find-orphaned-log-groups")
+		}
+		if stg.diffOutputFileName == stdoutFileName && stg.outputFileName == stdoutFileName {
+			logger.Fatal("the output and the diff report can't both be written to stdout")
+		}
+
+		previousList, previousColumns, err = readPreviousCSV(stg.diffAgainst, outOpts.delimiter)
+		if err != nil {
+			logger.Fatalw("invalid previous CSV file",
+				zap.String("diff_against", stg.diffAgainst),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if stg.stream {
+		err = validateStreamOutput(stg)
+		if err != nil {
+			logger.Fatalw("invalid streaming options",
+				zap.Error(err),
+			)
+		}
+	}
 
-	titles := lambdaFunctionsList[0].getTitleFields()
-	err = w.Write(titles)
+	if stg.findOrphanedGroups {
+		err = validateOrphanedLogGroupsOptions(stg)
+		if err != nil {
+			logger.Fatalw("invalid orphaned log groups options",
+				zap.Error(err),
+			)
+		}
+	}
+
+	if stg.skipLastInvoke && (stg.stream || stg.maxAgeDays > 0) {
+		logger.Fatal("-skip-last-invoke can't be used with -stream or -max-age-days")
+	}
+
+	if stg.withAliases && !stg.includeVersions {
+		logger.Fatal("-with-aliases requires -include-versions")
+	}
+
+	err = validateTimeFormat(stg.timeFormat)
+	if err != nil {
+		logger.Fatalw("invalid time format",
+			zap.Error(err),
+		)
+	}
+
+	err = validateLastInvokeSource(stg.lastInvokeSource)
+	if err != nil {
+		logger.Fatalw("invalid last invoke source",
+			zap.Error(err),
+		)
+	}
+
+	if stg.metricsLookbackDays < 1 {
+		logger.Fatal("-metrics-lookback-days must be at least 1")
+	}
+
+	var nameFilter *regexp.Regexp
+	if stg.nameFilter != "" {
+		nameFilter, err = regexp.Compile(stg.nameFilter)
+		if err != nil {
+			logger.Fatalw("invalid name filter",
+				zap.String("name_filter", stg.nameFilter),
+				zap.Error(err),
+			)
+		}
+	}
+
+	var modifiedAfter time.Time
+	if stg.modifiedAfter != "" {
+		modifiedAfter, err = parseDate(stg.modifiedAfter)
+		if err != nil {
+			logger.Fatalw("invalid modified after date",
+				zap.Error(err),
+			)
+		}
+	}
+
+	tagFilters, err := parseTagFilters(stg.tagFilters)
+	if err != nil {
+		logger.Fatalw("invalid tag filter",
+			zap.Error(err),
+		)
+	}
+
+	filters := scanFilters{
+		nameFilter:    nameFilter,
+		modifiedAfter: modifiedAfter,
+		tagFilters:    tagFilters,
+		seed:          stg.seed,
+	}
+	if stg.sample > 0 && filters.seed == 0 {
+		// the seed is logged, so that the same sample can be picked again with -seed
+		filters.seed = rand.Uint64()
+	}
+
+	// ctx is cancelled when the user interrupts or terminates the program or when the timeout is reached,
+	// in both cases the results gathered so far are still written to the output
+	signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// restore the default behavior once the first signal is received, so that a second Ctrl-C exits immediately
+		<-signalCtx.Done()
+		stop()
+	}()
+	ctx, cancel := context.WithTimeout(signalCtx, stg.timeout)
+	defer cancel()
+
+	err = validateMFAOptions(stg)
+	if err != nil {
+		logger.Fatalw("invalid MFA options",
+			zap.Error(err),
+		)
+	}
+
+	// each profile is scanned with its own config and service clients, then the results of all profiles are written together
+	if profiles := parseCommaSeparatedList(stg.profiles); len(profiles) > 0 {
+		err := validateProfilesOptions(stg)
+		if err != nil {
+			logger.Fatalw("invalid profiles options",
+				zap.Error(err),
+			)
+		}
+
+		app, lambdaFunctionsList := scanProfiles(ctx, logger, stg, profiles, filters)
+		if app == nil {
+			logger.Fatalw("none of the profiles could be scanned",
+				zap.Strings("profiles", profiles),
+			)
+		}
+
+		app.writeResults(ctx, signalCtx, startTime, lambdaFunctionsList, outOpts, previousList, previousColumns)
+		return
+	}
+
+	cfg, err := loadAWSConfig(ctx, logger, stg)
+	err = wrapSSOError(err, stg.awsProfileName)
 	if err != nil {
-		logger.Errorw("error when writing title",
+		logger.Fatalw("error when loading aws config",
+			zap.String("profile_name", stg.awsProfileName),
 			zap.Error(err),
 		)
 	}
 
-	for _, lambdaDetails := range lambdaFunctionsList {
-		record := []string{
-			lambdaDetails.Name,
-			lambdaDetails.Region,
-			lambdaDetails.Arn,
-			lambdaDetails.Description,
-			lambdaDetails.LastModified,
-			lambdaDetails.IamRole,
-			lambdaDetails.Runtime,
-			lambdaDetails.LastInvoked,
+	if stg.assumeRoleArn != "" {
+		logger.Debugw("assuming role",
+			zap.String("role_arn", stg.assumeRoleArn),
+			zap.String("role_session_name", stg.roleSessionName),
+		)
+		cfg.Credentials = newAssumeRoleCredentials(cfg, stg)
+	}
+
+	var functionEntries []functionEntry
+	if stg.functionsFile != "" {
+		if stg.regions != "" || stg.getAllRegions {
+			logger.Fatal("-functions-file can't be used with -regions or -all-regions")
 		}
 
-		err := w.Write(record)
+		functionEntries, err = readFunctionsFile(stg.functionsFile, cfg.Region)
 		if err != nil {
-			logger.Errorw("error when writing the entry",
-				zap.String("function_name", lambdaDetails.Name),
+			logger.Fatalw("invalid functions file",
+				zap.String("functions_file", stg.functionsFile),
 				zap.Error(err),
 			)
 		}
+
+		// only the regions of the listed functions are used, and they are validated like -regions
+		stg.regions = strings.Join(getFunctionsFileRegions(functionEntries), ",")
+	}
+
+	initStartTime := time.Now()
+	app, err := initializeApplication(ctx, logger, cfg, stg)
+	err = wrapSSOError(err, stg.awsProfileName)
+	if err != nil {
+		logger.Fatalw("error when initializing application struct",
+			zap.Error(err),
+		)
 	}
+	app.recordPhase("initialization", initStartTime)
 
-	logger.Infow("all the function details have been written to the output",
+	listStartTime := time.Now()
+	lambdaFunctionsList, err := app.listLambdaFunctions(ctx, functionEntries)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Fatalw("error when listing lambda function details",
+				zap.Error(err),
+			)
+		}
+
+		logger.Warnw("listing lambda function details was interrupted, continuing with partial results",
+			zap.Int("function_count", len(lambdaFunctionsList)),
+			zap.Error(err),
+		)
+	}
+	app.recordPhase("listing", listStartTime)
+
+	// the filters are not applied, since a log group is only orphaned if no function of the region uses it
+	if stg.findOrphanedGroups {
+		app.reportOrphanedLogGroups(ctx, lambdaFunctionsList, outOpts)
+		app.logPerformanceSummary(time.Since(startTime))
+		app.exitOnFailure(signalCtx.Err() != nil)
+		return
+	}
+
+	lambdaFunctionsList = app.filterLambdaFunctions(ctx, lambdaFunctionsList, filters)
+
+	if stg.dryRun {
+		logger.Info("dry run, skipping the last invoke time and the output file")
+		printRegionSummary(os.Stdout, lambdaFunctionsList, app.getRegions())
+		return
+	}
+
+	if stg.stream {
+		fileName := getFileName(stg.outputFileName, stg.outputFormat, outOpts.gzip, app.getFileNamePrefix())
+		logger.Infof("streaming the output to %q", fileName)
+
+		now := time.Now()
+		streamStartTime := now
+		write := func(w io.Writer, _ outputOptions) error {
+			return app.streamJSONL(ctx, w, lambdaFunctionsList, now)
+		}
+		if fileName == stdoutFileName {
+			err = writeEncoded(os.Stdout, outOpts, write)
+		} else {
+			// the file is written in place, so that the functions can be read as soon as they are written
+			err = writeToFileInPlace(fileName, stg.appendOutput, outOpts, write)
+		}
+		if err != nil {
+			logger.Errorw("error when streaming the output",
+				zap.Error(err),
+			)
+		}
+
+		app.recordPhase("last_invoke_and_output", streamStartTime)
+		app.addFunctionStats(lambdaFunctionsList, time.Now())
+
+		app.finishRun(startTime, fileName, filterByLastInvoke(lambdaFunctionsList, stg, now), signalCtx.Err() != nil)
+		return
+	}
+
+	lambdaFunctionsList = app.resolveLambdaFunctions(ctx, lambdaFunctionsList)
+	app.writeResults(ctx, signalCtx, startTime, lambdaFunctionsList, outOpts, previousList, previousColumns)
+}
+
+// writeResults sorts and formats the Lambda functions, writes them to the output and the diff report if it is chosen,
+// and finishes the run
+func (app *application) writeResults(ctx context.Context, signalCtx context.Context, startTime time.Time, lambdaFunctionsList []lambdaFunction, outOpts outputOptions, previousList []lambdaFunction, previousColumns []string) {
+	sortLambdaFunctions(lambdaFunctionsList, app.stg.sortBy, app.stg.sortDesc)
+	now := time.Now()
+	app.addFunctionStats(lambdaFunctionsList, now)
+	app.setLastInvokedAges(lambdaFunctionsList, now)
+	formatLastInvokedTimes(lambdaFunctionsList, app.stg.timeFormat, app.stg.lookbackDays, now)
+	app.formatLastModifiedTimes(lambdaFunctionsList)
+
+	if ctx.Err() != nil {
+		app.logger.Warnw("the run was interrupted, writing partial results",
+			zap.Error(ctx.Err()),
+		)
+	}
+
+	outputStartTime := time.Now()
+	fileName := getFileName(app.stg.outputFileName, app.stg.outputFormat, outOpts.gzip, app.getFileNamePrefix())
+	app.logger.Infof("writing the output to %q", fileName)
+	if app.stg.outputFormat == outputFormatSQLite {
+		// the scan time is the start of the run, so that all functions found by the same run share the same scan time
+		err := writeSQLite(context.WithoutCancel(ctx), fileName, lambdaFunctionsList, startTime)
+		if err != nil {
+			app.logger.Errorw("error when writing the output to the SQLite database",
+				zap.Error(err),
+			)
+		}
+	} else if fileName == stdoutFileName {
+		err := writeEncodedOutput(os.Stdout, outOpts, lambdaFunctionsList)
+		if err != nil {
+			app.logger.Errorw("error when writing the output to stdout",
+				zap.String("output_format", app.stg.outputFormat),
+				zap.Error(err),
+			)
+		}
+	} else if isS3URI(fileName) {
+		// the upload should still happen when the run was interrupted, so that the partial results are not lost
+		err := app.writeOutputToS3(context.WithoutCancel(ctx), fileName, app.stg.s3Region, outOpts, lambdaFunctionsList)
+		if err != nil {
+			app.logger.Errorw("error when writing the output to S3",
+				zap.String("output_format", app.stg.outputFormat),
+				zap.Error(err),
+			)
+		}
+	} else {
+		err := writeOutputToFile(fileName, app.stg.appendOutput, outOpts, lambdaFunctionsList)
+		if err != nil {
+			app.logger.Errorw("error when writing the output",
+				zap.String("output_format", app.stg.outputFormat),
+				zap.Error(err),
+			)
+		}
+	}
+
+	app.recordPhase("output", outputStartTime)
+
+	if app.stg.diffAgainst != "" {
+		app.reportDiff(previousList, previousColumns, lambdaFunctionsList, outOpts.delimiter)
+	}
+
+	app.finishRun(startTime, fileName, lambdaFunctionsList, signalCtx.Err() != nil)
+}
+
+// finishRun logs the summary of the run, emits the run metrics if they are chosen, prints the run result in quiet mode,
+// and exits with non-zero code if there's any error, or if no function is found with -strict.
+// If the run was interrupted by a signal, the exit code is 130
+func (app *application) finishRun(startTime time.Time, fileName string, lambdaFunctionsList []lambdaFunction, interrupted bool) {
+	app.logger.Infow("all the function details have been written to the output",
 		zap.String("file name", fileName),
 		zap.Int("number of functions", len(lambdaFunctionsList)),
 	)
+	app.logger.Infow("total code size across all functions",
+		zap.String("total_code_size", formatBytes(getTotalCodeSize(lambdaFunctionsList))),
+	)
+	if len(lambdaFunctionsList) == 0 && !app.stg.strict {
+		app.logger.Warnw("no lambda function found, check the profile, the regions, and the filters",
+			zap.Strings("regions", app.getRegions()),
+		)
+	}
+
+	app.logPerformanceSummary(time.Since(startTime))
+
+	result := runResult{
+		TotalFunctions:  len(lambdaFunctionsList),
+		RegionCounts:    countFunctionsPerRegion(lambdaFunctionsList, app.getRegions()),
+		ErrorCount:      len(app.getErrors()),
+		OutputFile:      fileName,
+		DurationSeconds: time.Since(startTime).Seconds(),
+	}
+
+	if app.stg.emitRunMetrics {
+		app.emitRunMetrics(result)
+	}
+
+	if app.stg.printRegionStats {
+		app.logger.Sync()
+		printRegionStats(os.Stderr, app.getRegions(), app.getRegionStats())
+	}
+
+	if app.stg.quiet {
+		// don't mix the run result with the output when the output is written to stdout
+		resultWriter := os.Stdout
+		if fileName == stdoutFileName {
+			resultWriter = os.Stderr
+		}
+		printRunResult(resultWriter, result)
+	}
+
+	app.exitOnFailure(interrupted)
+
+	if len(lambdaFunctionsList) == 0 && app.stg.strict {
+		app.logger.Errorw("no lambda function found with -strict, check the profile, the regions, and the filters",
+			zap.Strings("regions", app.getRegions()),
+		)
+		app.logger.Sync()
+		os.Exit(1)
+	}
 }
 
+// exitOnFailure prints the error summary if there's any error, and exits with non-zero code
+// if the run was interrupted or there's any error. If the run was interrupted by a signal, the exit code is 130
+func (app *application) exitOnFailure(interrupted bool) {
+	runErrors := app.getErrors()
+	if len(runErrors) > 0 {
+		app.logger.Sync()
+		printErrorSummary(os.Stderr, runErrors)
+	}
+
+	// exit with non-zero code if the run was interrupted or there's any error, so that partial failures can be detected
+	if interrupted {
+		app.logger.Sync()
+		os.Exit(interruptedExitCode)
+	}
+	if len(runErrors) > 0 {
+		os.Exit(1)
+	}
+}
+
+// interruptedExitCode is the exit code when the run is interrupted by a signal, following the shell convention
+const interruptedExitCode = 130
+
+const (
+	logFormatConsole = "console"
+	logFormatJSON    = "json"
+)
+
 // createLogger creates zap.SugaredLogger with debug or info logging level
-// depending on the input
-func createLogger(debugMode bool) *zap.SugaredLogger {
+// depending on the input. In quiet mode, only error logs are written, to stderr.
+// When the output is written to stdout, the logs are written to stderr so that they don't corrupt the output.
+// The logs are encoded as console or json depending on -log-format, and are also appended to -log-file if it is set
+func createLogger(stg settings) (*zap.SugaredLogger, error) {
+	debugMode := stg.debug
+
+	if stg.logFormat != logFormatConsole && stg.logFormat != logFormatJSON {
+		return nil, fmt.Errorf("unsupported log format %q, valid values are %q and %q", stg.logFormat, logFormatConsole, logFormatJSON)
+	}
+
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 
@@ -130,81 +797,260 @@ func createLogger(debugMode bool) *zap.SugaredLogger {
 		level = zap.NewAtomicLevelAt(zap.DebugLevel)
 	}
 
+	outputPath := "stdout"
+	if logsToStderr(stg) {
+		outputPath = "stderr"
+	}
+	if stg.quiet {
+		level = zap.NewAtomicLevelAt(zap.ErrorLevel)
+	}
+
+	outputPaths := []string{outputPath}
+	errorOutputPaths := []string{"stderr"}
+	if stg.logFile != "" {
+		// make sure the log file can be written before building the logger, so that the run fails fast otherwise
+		f, err := os.OpenFile(stg.logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o666)
+		if err != nil {
+			return nil, fmt.Errorf("log file is not writable: %w", err)
+		}
+		f.Close()
+
+		outputPaths = append(outputPaths, stg.logFile)
+		errorOutputPaths = append(errorOutputPaths, stg.logFile)
+	}
+
 	config := zap.Config{
 		Level:             level,
 		Development:       false,
 		DisableCaller:     !debugMode, // disable caller if log level is not debug
 		DisableStacktrace: !debugMode, // disable stack trace if log level is not debug
-		Encoding:          "console",
+		Encoding:          stg.logFormat,
 		EncoderConfig:     encoderConfig,
-		OutputPaths: []string{
-			"stdout",
-		},
-		ErrorOutputPaths: []string{
-			"stderr",
-		},
+		OutputPaths:       outputPaths,
+		ErrorOutputPaths:  errorOutputPaths,
 	}
 
-	return zap.Must(config.Build()).Sugar()
+	logger, err := config.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return logger.Sugar(), nil
+}
+
+// logsToStderr checks whether the logs are written to stderr instead of stdout,
+// which is the case in quiet mode or when the output is written to stdout
+func logsToStderr(stg settings) bool {
+	return stg.quiet || stg.outputFileName == stdoutFileName
+}
+
+// newRetryer creates the retryer used by all AWS service clients. Throttling and other retryable errors
+// are retried up to maxRetries times with exponential backoff and jitter.
+//
+// The client-side rate limiter of the standard retryer is disabled, because when scanning many functions
+// the retry quota is quickly exhausted and the calls fail without being retried
+func newRetryer(maxRetries int) aws.Retryer {
+	return retry.NewStandard(func(o *retry.StandardOptions) {
+		o.MaxAttempts = maxRetries + 1
+		o.RateLimiter = ratelimit.None
+	})
 }
 
-// initializeApplication creates application struct with logger and AWS Service Clients (ec2Client, lambdaClients, and cwLogsClients).
+// needsAccountId checks whether the account ID is written, which is when the AccountId column is written,
+// in the file name prefix, in the orphaned log groups report, or as the dimension of the run metrics
+func needsAccountId(stg settings) bool {
+	return stg.withAccountId || stg.profiles != "" || stg.prefixAccountId || stg.findOrphanedGroups || stg.emitRunMetrics ||
+		slices.Contains(parseCommaSeparatedList(stg.columns), "AccountId")
+}
+
+// initializeApplication creates application struct with logger and AWS Service Clients (ec2Client, lambdaClients, cwLogsClients, and cwClients).
 //
-// lambdaClients and cwLogsClients are created based on the number of regions.
-// If getAllRegions is set to true, it will populate the application struct with clients for all AWS Regions
-func initializeApplication(logger *zap.SugaredLogger, cfg aws.Config, getAllRegions bool) (*application, error) {
+// lambdaClients, cwLogsClients, and cwClients are created based on the number of regions.
+// If regions are explicitly chosen, it will populate the application struct with clients for those regions only.
+// Otherwise, if getAllRegions is set to true, it will populate the application struct with clients for all AWS Regions
+func initializeApplication(ctx context.Context, logger *zap.SugaredLogger, cfg aws.Config, stg settings) (*application, error) {
 	logger.Debug("initializing application struct")
 
 	app := &application{
-		logger:    logger,
-		cfg:       &cfg,
-		ec2Client: ec2.NewFromConfig(cfg),
+		logger:      logger,
+		stg:         stg,
+		profileName: getProfileName(stg),
+	}
+
+	// the API calls of all service clients are counted for the performance summary
+	// the throttled API calls are also counted, so that -adaptive-workers can back off
+	cfg.APIOptions = append(cfg.APIOptions, app.addAPICallCounter, app.addThrottleCounter)
+	app.cfg = &cfg
+
+	err := validateEndpointURL(stg.endpointURL, stg.useFIPS, stg.useDualStack)
+	if err != nil {
+		return nil, err
+	}
+
+	// the regions are listed from the default region, so it also needs a FIPS endpoint
+	if stg.useFIPS {
+		err := validateFIPSRegions([]string{cfg.Region})
+		if err != nil {
+			return nil, err
+		}
+	}
+	app.ec2Client = ec2.NewFromConfig(cfg, func(o *ec2.Options) {
+		setEndpointOptions(stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+	})
+	app.iamClient = iam.NewFromConfig(cfg, func(o *iam.Options) {
+		setEndpointOptions(stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+	})
+
+	// the account ID is only informational, so it is only taken from STS when it is written, and a failure is not fatal
+	app.accountId = "-"
+	if needsAccountId(stg) {
+		accountId, err := getAccountId(ctx, sts.NewFromConfig(cfg, func(o *sts.Options) {
+			setEndpointOptions(stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+		}))
+		if err != nil {
+			logger.Warnw("error when getting the account ID, writing it as -",
+				zap.Error(err),
+			)
+		} else {
+			app.accountId = accountId
+			logger.Debugw("got account ID",
+				zap.String("account_id", accountId),
+			)
+		}
+	}
+
+	chosenRegions := parseCommaSeparatedList(stg.regions)
+	excludedRegions := parseCommaSeparatedList(stg.excludeRegions)
+	if len(chosenRegions) > 0 && len(excludedRegions) > 0 {
+		return nil, errors.New("-regions and -exclude-regions can't be used together")
 	}
 
 	logger.Debugw("getting chosen regions",
-		zap.Bool("all_regions", getAllRegions),
+		zap.Bool("all_regions", stg.getAllRegions),
+		zap.Strings("chosen_regions", chosenRegions),
+		zap.Strings("excluded_regions", excludedRegions),
 	)
 	// get regions list based on the chosen parameters
 	regions := []string{}
-	if getAllRegions {
-		allOptedInRegions, err := app.getAllAvailableRegions()
+	if len(chosenRegions) > 0 {
+		// explicitly chosen opt-in regions are valid even when -exclude-opt-in-regions is set
+		enabledRegions, err := app.getEnabledRegions(ctx, true)
 		if err != nil {
-			app.logger.Fatalf("error when listing all available regions",
-				zap.Error(err),
-			)
+			return nil, fmt.Errorf("error when listing all enabled regions: %w", err)
+		}
+
+		err = validateRegions(chosenRegions, enabledRegions)
+		if err != nil {
+			return nil, err
+		}
+
+		regions = chosenRegions
+	} else if stg.getAllRegions {
+		enabledRegions, err := app.getEnabledRegions(ctx, !stg.excludeOptInRegions)
+		if err != nil {
+			return nil, fmt.Errorf("error when listing all enabled regions: %w", err)
 		}
 
-		regions = allOptedInRegions
+		regions = excludeRegions(enabledRegions, excludedRegions)
+		if len(excludedRegions) > 0 {
+			logger.Infow("excluded regions",
+				zap.Strings("excluded_regions", excludedRegions),
+			)
+		}
 	} else {
 		// if no specified region is chosen, use AWS CLI default region
 		regions = append(regions, cfg.Region)
 	}
 
-	// lambdaClients will hold all the service clients from all chosen regions.
+	if stg.useFIPS {
+		err := validateFIPSRegions(regions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// lambdaClients will hold all the service clients from all chosen regions keyed by region name.
 	// This will be used to query the AWS Service
-	lambdaClients := []*lambda.Client{}
+	lambdaClients := map[string]lambdaAPI{}
+	// cwLogsClients will hold the CloudWatch Logs clients keyed by region name,
+	// so that the workers can reuse the same client for all functions in a region
+	cwLogsClients := map[string]cwLogsAPI{}
+	// cwClients will hold the CloudWatch clients keyed by region name,
+	// which are used when the last invoke time is taken from the metrics
+	cwClients := map[string]metricDataGetter{}
 
 	logger.Debug("initializing service clients for chosen regions")
 	// Create AWS service clients for all chosen region and put it in the application struct
 	for _, region := range regions {
-		lambdaClient := lambda.NewFromConfig(cfg, func(o *lambda.Options) {
+		lambdaClients[region] = lambda.NewFromConfig(cfg, func(o *lambda.Options) {
+			o.Region = region
+			setEndpointOptions(stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+		})
+
+		cwLogsClients[region] = cloudwatchlogs.NewFromConfig(cfg, func(o *cloudwatchlogs.Options) {
+			o.Region = region
+			setEndpointOptions(stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+		})
+
+		cwClients[region] = cloudwatch.NewFromConfig(cfg, func(o *cloudwatch.Options) {
 			o.Region = region
+			setEndpointOptions(stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
 		})
-		lambdaClients = append(lambdaClients, lambdaClient)
+
+		endpoint, err := resolveLambdaEndpoint(ctx, region, stg.useFIPS, stg.useDualStack, stg.endpointURL)
+		logger.Debugw("resolved lambda endpoint",
+			zap.String("region", region),
+			zap.String("endpoint", endpoint),
+			zap.Error(err),
+		)
 	}
 	logger.Debug("service clients retrieved")
 
+	app.regions = regions
+	app.rateLimiters = newRegionRateLimiters(regions, stg.rateLimit)
 	app.lambdaClients = lambdaClients
+	app.cwLogsClients = cwLogsClients
+	app.cwClients = cwClients
 
 	return app, nil
 }
 
 // getFileName generates file name based on the user input. If the user does not input a file name,
-// it returns filename with format [timestamp].csv, e.g. 1744990200.csv
-func getFileName(inputFileName string) string {
+// it returns filename with format [prefix][timestamp].[outputFormat], e.g. 1744990200.csv or 1744990200.json,
+// with .gz appended if the output is compressed
+func getFileName(inputFileName string, outputFormat string, gzipOutput bool, prefix string) string {
 	if inputFileName == "" {
-		return fmt.Sprintf("%d.csv", time.Now().Unix())
+		fileName := fmt.Sprintf("%s%d.%s", prefix, time.Now().Unix(), getFileExtension(outputFormat))
+		if gzipOutput {
+			fileName += gzipFileExtension
+		}
+		return fileName
 	} else {
 		return inputFileName
 	}
 }
+
+// getFileNamePrefix returns the prefix of the generated output file name,
+// which is the account ID when -prefix-account-id is set
+func (app *application) getFileNamePrefix() string {
+	// the account ID is "-" when it couldn't be taken from STS
+	if !app.stg.prefixAccountId || app.accountId == "-" {
+		return ""
+	}
+
+	return app.accountId + "-"
+}
+
+// parseCommaSeparatedList splits a comma-separated user input into a list of values,
+// trimming the spaces around each value and ignoring empty values
+func parseCommaSeparatedList(input string) []string {
+	values := []string{}
+	for _, value := range strings.Split(input, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
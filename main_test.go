@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"go.uber.org/zap"
+)
+
+// fakeHTTPClient returns the responses in order, and repeats the last response once they are all used
+type fakeHTTPClient struct {
+	mu        sync.Mutex
+	responses []fakeHTTPResponse
+	requests  []*http.Request
+}
+
+// fakeHTTPResponse is the status code, the headers, and the body of a response of fakeHTTPClient
+type fakeHTTPResponse struct {
+	statusCode int
+	header     http.Header
+	body       string
+}
+
+func (f *fakeHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	response := f.responses[min(len(f.requests), len(f.responses)-1)]
+	f.requests = append(f.requests, req)
+
+	header := http.Header{"Content-Type": []string{"application/json"}}
+	for key, values := range response.header {
+		header[key] = values
+	}
+
+	return &http.Response{
+		StatusCode:    response.statusCode,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(response.body)),
+		ContentLength: int64(len(response.body)),
+		Request:       req,
+	}, nil
+}
+
+// newTestConfig returns an AWS config with static credentials that sends the requests to httpClient
+func newTestConfig(httpClient aws.HTTPClient) aws.Config {
+	return aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""),
+		HTTPClient:  httpClient,
+	}
+}
+
+func TestRetryerRetriesThrottledCalls(t *testing.T) {
+	throttled := fakeHTTPResponse{
+		statusCode: http.StatusTooManyRequests,
+		header:     http.Header{"X-Amzn-Errortype": []string{"TooManyRequestsException"}},
+		body:       `{"message":"Rate exceeded"}`,
+	}
+	succeeded := fakeHTTPResponse{
+		statusCode: http.StatusOK,
+		body:       `{"Functions":[{"FunctionName":"my-function"}]}`,
+	}
+
+	tests := []struct {
+		name         string
+		maxRetries   int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{
+			name:         "succeeds after two throttled calls",
+			maxRetries:   3,
+			wantAttempts: 3,
+		},
+		{
+			name:         "gives up after max retries",
+			maxRetries:   1,
+			wantAttempts: 2,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpClient := &fakeHTTPClient{responses: []fakeHTTPResponse{throttled, throttled, succeeded}}
+			cfg := newTestConfig(httpClient)
+
+			// the backoff is shortened so that the test doesn't wait for the real delays
+			cfg.Retryer = func() aws.Retryer {
+				return retry.AddWithMaxBackoffDelay(newRetryer(tt.maxRetries), time.Millisecond)
+			}
+
+			out, err := lambda.NewFromConfig(cfg).ListFunctions(context.Background(), &lambda.ListFunctionsInput{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (len(out.Functions) != 1 || aws.ToString(out.Functions[0].FunctionName) != "my-function") {
+				t.Errorf("functions = %+v, want my-function", out.Functions)
+			}
+
+			if len(httpClient.requests) != tt.wantAttempts {
+				t.Errorf("sent %d requests, want %d", len(httpClient.requests), tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestWorkersPerPhase(t *testing.T) {
+	tests := []struct {
+		name            string
+		stg             settings
+		wantListWorkers int
+		wantLogsWorkers int
+	}{
+		{name: "defaults to max workers", stg: settings{maxWorkers: 10}, wantListWorkers: 10, wantLogsWorkers: 10},
+		{name: "list workers", stg: settings{maxWorkers: 10, listWorkers: 2}, wantListWorkers: 2, wantLogsWorkers: 10},
+		{name: "logs workers", stg: settings{maxWorkers: 10, logsWorkers: 50}, wantListWorkers: 10, wantLogsWorkers: 50},
+		{name: "both", stg: settings{maxWorkers: 10, listWorkers: 3, logsWorkers: 30}, wantListWorkers: 3, wantLogsWorkers: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stg.getListWorkers(); got != tt.wantListWorkers {
+				t.Errorf("getListWorkers() = %d, want %d", got, tt.wantListWorkers)
+			}
+			if got := tt.stg.getLogsWorkers(); got != tt.wantLogsWorkers {
+				t.Errorf("getLogsWorkers() = %d, want %d", got, tt.wantLogsWorkers)
+			}
+		})
+	}
+}
+
+func TestFinishRunEmptyResult(t *testing.T) {
+	// finishRun exits the process with -strict, so it is run in a subprocess that runs only this test
+	if strict := os.Getenv("ALLI_LISTER_TEST_FINISH_RUN_STRICT"); strict != "" {
+		app := newTestApplication()
+		app.logger = zap.NewExample().Sugar()
+		app.stg.strict = strict == "true"
+		app.finishRun(time.Now(), "output.csv", []lambdaFunction{}, false)
+		return
+	}
+
+	tests := []struct {
+		name         string
+		strict       bool
+		wantExitCode int
+		wantLog      string
+	}{
+		{name: "non-strict", strict: false, wantExitCode: 0, wantLog: "no lambda function found, check the profile"},
+		{name: "strict", strict: true, wantExitCode: 1, wantLog: "no lambda function found with -strict"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestFinishRunEmptyResult$")
+			cmd.Env = append(os.Environ(), fmt.Sprintf("ALLI_LISTER_TEST_FINISH_RUN_STRICT=%t", tt.strict))
+			output, err := cmd.CombinedOutput()
+
+			exitCode := 0
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				exitCode = exitErr.ExitCode()
+			} else if err != nil {
+				t.Fatalf("error when running the subprocess: %v", err)
+			}
+
+			if exitCode != tt.wantExitCode {
+				t.Errorf("exit code = %d, want %d, output:\n%s", exitCode, tt.wantExitCode, output)
+			}
+			if !strings.Contains(string(output), tt.wantLog) {
+				t.Errorf("output doesn't contain %q:\n%s", tt.wantLog, output)
+			}
+		})
+	}
+}
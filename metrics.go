@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"go.uber.org/zap"
+)
+
+const (
+	lambdaMetricsNamespace   = "AWS/Lambda"
+	invocationsMetricName    = "Invocations"
+	functionNameDimension    = "FunctionName"
+	invocationsMetricQueryID = "invocations"
+
+	// invocationsMetricPeriodInSeconds is the granularity of the Invocations datapoints.
+	// One hour datapoints are retained by CloudWatch for 455 days, which allows long lookback windows
+	invocationsMetricPeriodInSeconds = 3600
+)
+
+// getLambdaFunctionLastInvokeTimeFromMetrics queries the CloudWatch Invocations metric of the Lambda function
+// of the job, and returns the timestamp of the most recent non-zero datapoint
+// and the total number of invocations in the lookback window of -lookback-days, or -metrics-lookback-days if it is not set.
+// The timestamp is the start of the hour in which the function was last invoked.
+// If there's no invocation in the lookback window, the resulting last invocation timestamp is "-" and the count is 0
+func (app *application) getLambdaFunctionLastInvokeTimeFromMetrics(ctx context.Context, currentJob job) lastInvokeResult {
+	result := lastInvokeResult{lastInvoked: "-"}
+
+	cwClient := app.cwClients[currentJob.region]
+
+	endTime := time.Now()
+	startTime := endTime.AddDate(0, 0, -app.stg.getInvocationsLookbackDays())
+
+	input := &cloudwatch.GetMetricDataInput{
+		StartTime: aws.Time(startTime),
+		EndTime:   aws.Time(endTime),
+		ScanBy:    types.ScanByTimestampDescending,
+		MetricDataQueries: []types.MetricDataQuery{
+			{
+				Id: aws.String(invocationsMetricQueryID),
+				MetricStat: &types.MetricStat{
+					Metric: &types.Metric{
+						Namespace:  aws.String(lambdaMetricsNamespace),
+						MetricName: aws.String(invocationsMetricName),
+						Dimensions: []types.Dimension{
+							{
+								Name:  aws.String(functionNameDimension),
+								Value: aws.String(currentJob.functionName),
+							},
+						},
+					},
+					Period: aws.Int32(invocationsMetricPeriodInSeconds),
+					Stat:   aws.String(string(types.StatisticSum)),
+				},
+			},
+		},
+	}
+
+	var lastInvoked time.Time
+	var invokeCount int64
+	release := app.acquireWorkerSlot()
+	err := app.waitForRateLimit(ctx, currentJob.region)
+	if err == nil {
+		lastInvoked, invokeCount, err = getInvocationsSummary(ctx, cwClient, input)
+	}
+	release()
+	if err != nil {
+		app.logger.Debugw("error when getting invocations metric",
+			zap.String("function_name", currentJob.functionName),
+			zap.Error(err),
+		)
+		result.err = err
+	} else if lastInvoked.IsZero() {
+		result.invokeCount = "0"
+		app.logger.Debugw("no invocation in the lookback window for lambda function",
+			zap.String("function_name", currentJob.functionName),
+			zap.Int("lookback_days", app.stg.getInvocationsLookbackDays()),
+		)
+	} else {
+		result.lastInvoked = app.inOutputTimezone(lastInvoked).Format(lastInvokedTimeFormat)
+		result.invokeCount = fmt.Sprint(invokeCount)
+	}
+
+	return result
+}
+
+// getInvocationsSummary pages through all metric datapoints, which are sorted from the newest,
+// and returns the timestamp of the first non-zero datapoint and the sum of all datapoints.
+// It returns zero time if there's no non-zero datapoint
+func getInvocationsSummary(ctx context.Context, cwClient metricDataGetter, input *cloudwatch.GetMetricDataInput) (time.Time, int64, error) {
+	var lastInvoked time.Time
+	var total float64
+	for {
+		out, err := cwClient.GetMetricData(ctx, input)
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+
+		for _, result := range out.MetricDataResults {
+			for i, value := range result.Values {
+				total += value
+				if value > 0 && lastInvoked.IsZero() && i < len(result.Timestamps) {
+					lastInvoked = result.Timestamps[i]
+				}
+			}
+		}
+
+		if out.NextToken == nil {
+			return lastInvoked, int64(math.Round(total)), nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+// validateLastInvokeSource makes sure that the chosen last invoke source is supported
+func validateLastInvokeSource(lastInvokeSource string) error {
+	switch lastInvokeSource {
+	case lastInvokeSourceLogs, lastInvokeSourceMetrics:
+		return nil
+	default:
+		return fmt.Errorf("unsupported last invoke source %q, valid values are %q and %q",
+			lastInvokeSource, lastInvokeSourceLogs, lastInvokeSourceMetrics)
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"go.uber.org/zap"
+)
+
+// orphanedLogGroup contains the details of a Lambda log group whose function no longer exists
+// `json` tag is the key of the field in the resulting JSON and JSONL file
+type orphanedLogGroup struct {
+	LogGroupName  string `json:"log_group_name"`
+	Region        string `json:"region"`
+	AccountId     string `json:"account_id"`
+	StoredBytes   int64  `json:"stored_bytes"`
+	RetentionDays string `json:"retention_days"`
+	CreationTime  string `json:"creation_time"`
+}
+
+// validateOrphanedLogGroupsOptions makes sure that the options can be used with -find-orphaned-log-groups.
+// The orphaned log groups report has its own columns, and needs all functions of the regions to be listed
+func validateOrphanedLogGroupsOptions(stg settings) error {
+	switch {
+	case stg.outputFormat != outputFormatCSV && stg.outputFormat != outputFormatJSON && stg.outputFormat != outputFormatJSONL:
+		return fmt.Errorf("finding orphaned log groups is only supported with the %q, %q, and %q output formats",
+			outputFormatCSV, outputFormatJSON, outputFormatJSONL)
+	case isS3URI(stg.outputFileName):
+		return fmt.Errorf("finding orphaned log groups is not supported with S3 output")
+	case stg.functionsFile != "":
+		return fmt.Errorf("finding orphaned log groups can't be used with -functions-file")
+	case stg.stream:
+		return fmt.Errorf("finding orphaned log groups can't be used with -stream")
+	case stg.dryRun:
+		return fmt.Errorf("finding orphaned log groups can't be used with -dry-run")
+	case stg.columns != "":
+		return fmt.Errorf("finding orphaned log groups can't be used with -columns")
+	default:
+		return nil
+	}
+}
+
+// getAllOrphanedLogGroups returns the /aws/lambda/ log groups of all regions that don't belong to any of the
+// Lambda functions in lambdaFunctionsList, sorted by region then log group name.
+// A log group belongs to a function if it is named after the function, or if the function is configured to log to it.
+// Each region is described in its own goroutine, with at most maxWorkers regions being described at the same time.
+// The regions that can't be described are recorded as errors and skipped
+func (app *application) getAllOrphanedLogGroups(ctx context.Context, lambdaFunctionsList []lambdaFunction, maxWorkers int) []orphanedLogGroup {
+	app.logger.Info("getting orphaned log groups")
+
+	usedLogGroups := map[string]bool{}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		usedLogGroups[lambdaDetails.Region+"/"+lambdaLogGroupPrefix+lambdaDetails.Name] = true
+		usedLogGroups[lambdaDetails.Region+"/"+lambdaDetails.LogGroup] = true
+	}
+
+	var mu sync.Mutex
+	var orphanedLogGroups []orphanedLogGroup
+	semaphore := make(chan struct{}, maxWorkers)
+	wg := &sync.WaitGroup{}
+
+	for _, region := range app.regions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			regionLogGroups, err := app.getLambdaLogGroups(ctx, region)
+			if err != nil {
+				app.logger.Warnw("error when describing log groups, skipping the region",
+					zap.String("region", region),
+					zap.Error(err),
+				)
+				app.recordError(region, "", "DescribeLogGroups", err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, logGroup := range regionLogGroups {
+				if !usedLogGroups[region+"/"+logGroup.LogGroupName] {
+					orphanedLogGroups = append(orphanedLogGroups, logGroup)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	slices.SortFunc(orphanedLogGroups, func(a, b orphanedLogGroup) int {
+		return cmp.Or(
+			cmp.Compare(a.Region, b.Region),
+			cmp.Compare(a.LogGroupName, b.LogGroupName),
+		)
+	})
+
+	app.logger.Infow("got orphaned log groups",
+		zap.Int("orphaned_log_group_count", len(orphanedLogGroups)),
+	)
+
+	return orphanedLogGroups
+}
+
+// getLambdaLogGroups returns all log groups of the region whose name starts with /aws/lambda/.
+// If there's an error, the log groups gathered so far are returned together with the error
+func (app *application) getLambdaLogGroups(ctx context.Context, region string) ([]orphanedLogGroup, error) {
+	cwLogsClient := app.cwLogsClients[region]
+	in := &cloudwatchlogs.DescribeLogGroupsInput{
+		LogGroupNamePrefix: aws.String(lambdaLogGroupPrefix),
+	}
+
+	logGroups := []orphanedLogGroup{}
+	for {
+		err := app.waitForRateLimit(ctx, region)
+		if err != nil {
+			return logGroups, err
+		}
+
+		out, err := cwLogsClient.DescribeLogGroups(ctx, in)
+		if err != nil {
+			return logGroups, err
+		}
+
+		for _, logGroup := range out.LogGroups {
+			retentionDays := "never"
+			if logGroup.RetentionInDays != nil {
+				retentionDays = fmt.Sprint(*logGroup.RetentionInDays)
+			}
+
+			creationTime := "-"
+			if logGroup.CreationTime != nil {
+				creationTime = app.inOutputTimezone(time.UnixMilli(*logGroup.CreationTime)).Format(lastInvokedTimeFormat)
+			}
+
+			logGroups = append(logGroups, orphanedLogGroup{
+				LogGroupName:  aws.ToString(logGroup.LogGroupName),
+				Region:        region,
+				AccountId:     app.accountId,
+				StoredBytes:   aws.ToInt64(logGroup.StoredBytes),
+				RetentionDays: retentionDays,
+				CreationTime:  creationTime,
+			})
+		}
+
+		if out.NextToken == nil {
+			return logGroups, nil
+		}
+		in.NextToken = out.NextToken
+	}
+}
+
+// writeOrphanedLogGroups writes the orphaned log groups to w using the chosen output format
+func writeOrphanedLogGroups(w io.Writer, opts outputOptions, orphanedLogGroups []orphanedLogGroup) error {
+	switch opts.format {
+	case outputFormatJSON:
+		// make sure an empty result is written as [] instead of null
+		if orphanedLogGroups == nil {
+			orphanedLogGroups = []orphanedLogGroup{}
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(orphanedLogGroups)
+	case outputFormatJSONL:
+		enc := json.NewEncoder(w)
+		for _, logGroup := range orphanedLogGroups {
+			err := enc.Encode(logGroup)
+			if err != nil {
+				return fmt.Errorf("error when writing the entry for log group %q: %w", logGroup.LogGroupName, err)
+			}
+		}
+		return nil
+	default:
+		return writeOrphanedLogGroupsCSV(w, opts, orphanedLogGroups)
+	}
+}
+
+// writeOrphanedLogGroupsCSV writes the title row followed by one row per orphaned log group.
+// If skipHeader is set, the title row is not written
+func writeOrphanedLogGroupsCSV(w io.Writer, opts outputOptions, orphanedLogGroups []orphanedLogGroup) error {
+	cw := csv.NewWriter(w)
+	if opts.delimiter != 0 {
+		cw.Comma = opts.delimiter
+	}
+
+	if !opts.skipHeader {
+		err := cw.Write([]string{"Log Group Name", "Region", "Account ID", "Stored Bytes", "Retention (Days)", "Creation Time"})
+		if err != nil {
+			return fmt.Errorf("error when writing title: %w", err)
+		}
+	}
+
+	for _, logGroup := range orphanedLogGroups {
+		err := cw.Write([]string{
+			logGroup.LogGroupName,
+			logGroup.Region,
+			logGroup.AccountId,
+			fmt.Sprint(logGroup.StoredBytes),
+			logGroup.RetentionDays,
+			logGroup.CreationTime,
+		})
+		if err != nil {
+			return fmt.Errorf("error when writing the entry for log group %q: %w", logGroup.LogGroupName, err)
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// reportOrphanedLogGroups finds the orphaned log groups of the listed functions and writes them to the output
+// instead of the functions
+func (app *application) reportOrphanedLogGroups(ctx context.Context, lambdaFunctionsList []lambdaFunction, opts outputOptions) {
+	orphanStartTime := time.Now()
+	orphanedLogGroups := app.getAllOrphanedLogGroups(ctx, lambdaFunctionsList, app.stg.getListWorkers())
+	app.recordPhase("orphaned_log_groups", orphanStartTime)
+
+	outputStartTime := time.Now()
+	fileName := getFileName(app.stg.outputFileName, opts.format, opts.gzip, app.getFileNamePrefix())
+	app.logger.Infof("writing the orphaned log groups to %q", fileName)
+
+	write := func(w io.Writer, opts outputOptions) error {
+		return writeOrphanedLogGroups(w, opts, orphanedLogGroups)
+	}
+
+	var err error
+	if fileName == stdoutFileName {
+		err = writeEncoded(os.Stdout, opts, write)
+	} else {
+		err = writeToFile(fileName, app.stg.appendOutput, opts, write)
+	}
+	if err != nil {
+		app.logger.Errorw("error when writing the orphaned log groups",
+			zap.String("output_format", opts.format),
+			zap.Error(err),
+		)
+	}
+	app.recordPhase("output", outputStartTime)
+
+	var storedBytes int64
+	for _, logGroup := range orphanedLogGroups {
+		storedBytes += logGroup.StoredBytes
+	}
+
+	app.logger.Infow("all the orphaned log groups have been written to the output",
+		zap.String("file name", fileName),
+		zap.Int("number of log groups", len(orphanedLogGroups)),
+		zap.String("total_stored_bytes", formatBytes(storedBytes)),
+	)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// outputOptions contains the user choices on how the output is written
+type outputOptions struct {
+	format string
+
+	// columns are the struct field names of lambdaFunction that are written in the CSV output, in order.
+	// If empty, all fields are written
+	columns []string
+
+	// jsonColumns are the struct field names of lambdaFunction that are written in the JSON and JSONL output,
+	// which are not changed by -columns. If empty, all fields are written
+	jsonColumns []string
+
+	// skipHeader skips writing the title row of the CSV output, e.g. when appending to an existing file
+	skipHeader bool
+
+	// gzip compresses the output with gzip
+	gzip bool
+
+	// delimiter is the field delimiter of the CSV output
+	delimiter rune
+
+	// truncateDescription is the maximum number of characters of the description in the CSV output.
+	// When it is more than 0, the newlines in the description are also replaced with spaces
+	truncateDescription int
+}
+
+const (
+	outputFormatCSV      = "csv"
+	outputFormatJSON     = "json"
+	outputFormatJSONL    = "jsonl"
+	outputFormatMarkdown = "markdown"
+	outputFormatHTML     = "html"
+	outputFormatXLSX     = "xlsx"
+	outputFormatSQLite   = "sqlite"
+
+	gzipFileExtension = ".gz"
+
+	// stdoutFileName is the output file name that writes the output to stdout
+	stdoutFileName = "-"
+)
+
+// validateOutputFormat makes sure that the chosen output format is supported
+func validateOutputFormat(outputFormat string) error {
+	switch outputFormat {
+	case outputFormatCSV, outputFormatJSON, outputFormatJSONL, outputFormatMarkdown, outputFormatHTML, outputFormatXLSX, outputFormatSQLite:
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format %q, valid values are %q, %q, %q, %q, %q, %q, and %q",
+			outputFormat, outputFormatCSV, outputFormatJSON, outputFormatJSONL, outputFormatMarkdown, outputFormatHTML, outputFormatXLSX, outputFormatSQLite)
+	}
+}
+
+// writeOutput writes the Lambda function details to w with the record writer of the chosen output format.
+// The sqlite output format is written to a database file by writeSQLite instead.
+// If skipHeader is set, the title row is not written
+func writeOutput(w io.Writer, opts outputOptions, lambdaFunctionsList []lambdaFunction) error {
+	rw := newRecordWriter(w, opts)
+
+	if !opts.skipHeader {
+		err := rw.WriteHeader(lambdaFunction{}.getTitleFields(opts.columns))
+		if err != nil {
+			return fmt.Errorf("error when writing title: %w", err)
+		}
+	}
+
+	for _, lambdaDetails := range lambdaFunctionsList {
+		err := rw.WriteRecord(lambdaDetails)
+		if err != nil {
+			return fmt.Errorf("error when writing the entry for function %q: %w", lambdaDetails.Name, err)
+		}
+	}
+
+	return rw.Close()
+}
+
+// writeOutputToFile writes the output to a local file. The file is overwritten unless appendMode is set,
+// in which case the output is appended and the title row is only written if the file is new or empty
+func writeOutputToFile(fileName string, appendMode bool, opts outputOptions, lambdaFunctionsList []lambdaFunction) error {
+	return writeToFile(fileName, appendMode, opts, func(w io.Writer, opts outputOptions) error {
+		return writeOutput(w, opts, lambdaFunctionsList)
+	})
+}
+
+// writeToFile writes the local file with write, like writeToFileInPlace. Unless appendMode is set, the output is
+// written to a temporary file in the same directory, which replaces fileName only once it is complete,
+// so that the other programs reading the file never see a partial output, e.g. if the run is killed while writing
+func writeToFile(fileName string, appendMode bool, opts outputOptions, write func(w io.Writer, opts outputOptions) error) error {
+	if appendMode {
+		return writeToFileInPlace(fileName, true, opts, write)
+	}
+
+	tempFileName := getTempFileName(fileName)
+	err := writeToFileInPlace(tempFileName, false, opts, write)
+	if err != nil {
+		os.Remove(tempFileName)
+		return err
+	}
+
+	err = os.Rename(tempFileName, fileName)
+	if err != nil {
+		os.Remove(tempFileName)
+		return fmt.Errorf("error when replacing the file with the temporary file: %w", err)
+	}
+
+	return nil
+}
+
+// getTempFileName returns the name of the temporary file of writeToFile, which is a hidden file
+// in the same directory as fileName so that it can be renamed atomically
+func getTempFileName(fileName string) string {
+	return filepath.Join(filepath.Dir(fileName), fmt.Sprintf(".%s.%d.tmp", filepath.Base(fileName), os.Getpid()))
+}
+
+// writeToFileInPlace opens the local file and passes it to write, compressing the output with gzip if it is chosen.
+// The file is overwritten unless appendMode is set, in which case opts.skipHeader is also set if the file is not empty
+func writeToFileInPlace(fileName string, appendMode bool, opts outputOptions, write func(w io.Writer, opts outputOptions) error) error {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendMode {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	f, err := os.OpenFile(fileName, flags, 0o666)
+	if err != nil {
+		return fmt.Errorf("error when creating a file: %w", err)
+	}
+	defer f.Close()
+
+	if appendMode {
+		fileInfo, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("error when checking the size of the file: %w", err)
+		}
+
+		opts.skipHeader = opts.skipHeader || fileInfo.Size() > 0
+	}
+
+	err = writeEncoded(f, opts, write)
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// writeEncodedOutput writes the output to w, compressing it with gzip if it is chosen
+func writeEncodedOutput(w io.Writer, opts outputOptions, lambdaFunctionsList []lambdaFunction) error {
+	return writeEncoded(w, opts, func(w io.Writer, opts outputOptions) error {
+		return writeOutput(w, opts, lambdaFunctionsList)
+	})
+}
+
+// writeEncoded passes w to write, wrapping it with a gzip writer if gzip is chosen.
+// The gzip writer is closed before returning, so that all compressed data is flushed to w
+func writeEncoded(w io.Writer, opts outputOptions, write func(w io.Writer, opts outputOptions) error) error {
+	if !opts.gzip {
+		return write(w, opts)
+	}
+
+	gw := gzip.NewWriter(w)
+
+	err := write(gw, opts)
+	if err != nil {
+		gw.Close()
+		return err
+	}
+
+	return gw.Close()
+}
+
+// parseDelimiter parses the user input CSV delimiter, which must be a single character.
+// The escape sequence \t is accepted for a tab, e.g. to write TSV output
+func parseDelimiter(input string) (rune, error) {
+	if input == `\t` {
+		return '\t', nil
+	}
+
+	runes := []rune(input)
+	if len(runes) != 1 {
+		return 0, fmt.Errorf("invalid delimiter %q, it must be a single character", input)
+	}
+
+	delimiter := runes[0]
+	if delimiter == '\n' || delimiter == '\r' || delimiter == '"' || delimiter == utf8.RuneError {
+		return 0, fmt.Errorf("invalid delimiter %q, it can't be a newline, a quote, or an invalid character", input)
+	}
+
+	return delimiter, nil
+}
+
+// formatMarkdownRow joins the cells into a Markdown table row. The pipes in the cells are escaped
+// and the newlines are replaced with spaces, so that each cell stays in its column
+func formatMarkdownRow(cells []string) string {
+	replacer := strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ", "\r", " ")
+
+	escapedCells := make([]string, len(cells))
+	for i, cell := range cells {
+		escapedCells[i] = replacer.Replace(cell)
+	}
+
+	return "| " + strings.Join(escapedCells, " | ") + " |"
+}
+
+// getFileExtension returns the extension of the generated output file name for the output format
+func getFileExtension(outputFormat string) string {
+	if outputFormat == outputFormatMarkdown {
+		return "md"
+	}
+
+	return outputFormat
+}
+
+// truncateDescription replaces the newlines in the description with spaces
+// and caps its length at maxLength characters
+func truncateDescription(description string, maxLength int) string {
+	description = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(description)
+
+	runes := []rune(description)
+	if len(runes) > maxLength {
+		return string(runes[:maxLength])
+	}
+
+	return description
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// newTestFunctions returns two Lambda functions with the fields used by the output tests
+func newTestFunctions() []lambdaFunction {
+	return []lambdaFunction{
+		{Name: "alpha", Region: "us-east-1", Description: "first function", Runtime: "python3.12", LastInvoked: "2024-05-01T12:30:00+00:00"},
+		{Name: "bravo", Region: "eu-west-1", Description: "second | function", Runtime: "nodejs20.x", LastInvoked: "-"},
+	}
+}
+
+func TestWriteOutputToFileGzip(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "output.csv.gz")
+	opts := outputOptions{format: outputFormatCSV, columns: []string{"Name", "Region", "LastInvoked"}, gzip: true}
+
+	err := writeOutputToFile(fileName, false, opts, newTestFunctions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("the output is not gzip compressed: %v", err)
+	}
+
+	records, err := csv.NewReader(gr).ReadAll()
+	if err != nil {
+		t.Fatalf("error when reading the decompressed output: %v", err)
+	}
+
+	wantHeader := []string{"Function Name", "Region", "Last Invoked"}
+	if len(records) != 3 || !slices.Equal(records[0], wantHeader) {
+		t.Fatalf("records = %q, want the header %q and 2 functions", records, wantHeader)
+	}
+	if !slices.Equal(records[1], []string{"alpha", "us-east-1", "2024-05-01T12:30:00+00:00"}) {
+		t.Errorf("first record = %q", records[1])
+	}
+}
+
+func TestTruncateDescription(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		maxLength   int
+		want        string
+	}{
+		{name: "shorter than the limit", description: "abc", maxLength: 5, want: "abc"},
+		{name: "exactly the limit", description: "abcde", maxLength: 5, want: "abcde"},
+		{name: "one more than the limit", description: "abcdef", maxLength: 5, want: "abcde"},
+		{name: "empty", description: "", maxLength: 5, want: ""},
+		{name: "multi-byte characters are counted once", description: "héllo wörld", maxLength: 7, want: "héllo w"},
+		{name: "newlines are replaced", description: "line one\nline two\r\nline three\rend", maxLength: 100, want: "line one line two line three end"},
+		{name: "replaced newlines count as one character", description: "ab\r\ncd", maxLength: 3, want: "ab "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateDescription(tt.description, tt.maxLength); got != tt.want {
+				t.Errorf("truncateDescription(%q, %d) = %q, want %q", tt.description, tt.maxLength, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteOutputMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	opts := outputOptions{format: outputFormatMarkdown, columns: []string{"Name", "Description", "Runtime"}}
+
+	err := writeOutput(&buf, opts, newTestFunctions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "| Function Name | Function Description | Runtime |\n" +
+		"| --- | --- | --- |\n" +
+		"| alpha | first function | python3.12 |\n" +
+		"| bravo | second \\| function | nodejs20.x |\n"
+	if buf.String() != want {
+		t.Errorf("markdown output =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestFormatMarkdownRow(t *testing.T) {
+	tests := []struct {
+		name  string
+		cells []string
+		want  string
+	}{
+		{name: "separator row", cells: []string{"---", "---"}, want: "| --- | --- |"},
+		{name: "escaped pipe", cells: []string{"a|b"}, want: "| a\\|b |"},
+		{name: "newlines", cells: []string{"line one\nline two\r\nline three"}, want: "| line one line two line three |"},
+		{name: "empty cell", cells: []string{"", "x"}, want: "|  | x |"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatMarkdownRow(tt.cells); got != tt.want {
+				t.Errorf("formatMarkdownRow(%q) = %q, want %q", tt.cells, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteOutputTabDelimiter(t *testing.T) {
+	delimiter, err := parseDelimiter(`\t`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	opts := outputOptions{format: outputFormatCSV, columns: []string{"Name", "Description"}, delimiter: delimiter}
+	lambdaFunctionsList := []lambdaFunction{{Name: "alpha", Description: "with\ttab, and comma"}}
+
+	err = writeOutput(&buf, opts, lambdaFunctionsList)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := csv.NewReader(&buf)
+	r.Comma = '\t'
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("error when reading the TSV output back: %v", err)
+	}
+
+	want := [][]string{{"Function Name", "Function Description"}, {"alpha", "with\ttab, and comma"}}
+	if len(records) != len(want) || !slices.Equal(records[0], want[0]) || !slices.Equal(records[1], want[1]) {
+		t.Errorf("records = %q, want %q", records, want)
+	}
+}
+
+func TestParseDelimiter(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    rune
+		wantErr bool
+	}{
+		{input: ",", want: ','},
+		{input: ";", want: ';'},
+		{input: `\t`, want: '\t'},
+		{input: "\t", want: '\t'},
+		{input: "|", want: '|'},
+		{input: "§", want: '§'},
+		{input: "", wantErr: true},
+		{input: ",;", wantErr: true},
+		{input: "\n", wantErr: true},
+		{input: "\r", wantErr: true},
+		{input: `"`, wantErr: true},
+		{input: "\xff", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseDelimiter(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDelimiter(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseDelimiter(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteOutputSkipHeader(t *testing.T) {
+	var buf bytes.Buffer
+	opts := outputOptions{format: outputFormatCSV, columns: []string{"Name", "Region"}, skipHeader: true}
+
+	err := writeOutput(&buf, opts, newTestFunctions())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("error when reading the output back: %v", err)
+	}
+	if len(records) != 2 || !slices.Equal(records[0], []string{"alpha", "us-east-1"}) {
+		t.Errorf("records = %q, want the first row to be the alpha function", records)
+	}
+}
+
+func TestWriteOutputToFileAppendSkipsHeader(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "output.csv")
+	opts := outputOptions{format: outputFormatCSV, columns: []string{"Name", "Region"}}
+
+	// the title row is only written to the new file, and not again when the next run appends to it
+	for range 2 {
+		err := writeOutputToFile(fileName, true, opts, newTestFunctions())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("error when reading the output back: %v", err)
+	}
+	if len(records) != 5 || !slices.Equal(records[0], []string{"Function Name", "Region"}) || !slices.Equal(records[3], []string{"alpha", "us-east-1"}) {
+		t.Errorf("records = %q, want one title row and 4 functions", records)
+	}
+}
+
+func TestWriteToFileFailureKeepsExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "output.csv")
+	err := os.WriteFile(fileName, []byte("previous output\n"), 0o666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the write fails after a part of the output is written
+	writeErr := errors.New("listing failed")
+	err = writeToFile(fileName, false, outputOptions{format: outputFormatCSV}, func(w io.Writer, opts outputOptions) error {
+		_, err := io.WriteString(w, "partial output\n")
+		if err != nil {
+			return err
+		}
+		return writeErr
+	})
+	if !errors.Is(err, writeErr) {
+		t.Fatalf("writeToFile() = %v, want %v", err, writeErr)
+	}
+
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "previous output\n" {
+		t.Errorf("file content = %q, want the previous output", content)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d files, want only the output file without the temporary file", len(entries))
+	}
+}
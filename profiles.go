@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// validateProfilesOptions makes sure that -profiles is only used with the options that can run once per profile
+// and merge the results of all profiles
+func validateProfilesOptions(stg settings) error {
+	switch {
+	case stg.useEnvCreds:
+		return errors.New("-profiles can't be used with -use-env-credentials")
+	case stg.assumeRoleArn != "":
+		return errors.New("-profiles can't be used with -assume-role-arn, set role_arn in the profiles instead")
+	case stg.functionsFile != "":
+		return errors.New("-profiles can't be used with -functions-file")
+	case stg.stream || stg.findOrphanedGroups || stg.dryRun:
+		return errors.New("-profiles can't be used with -stream, -find-orphaned-log-groups, or -dry-run")
+	default:
+		return nil
+	}
+}
+
+// getProfileName returns the value of the Profile column, which is "-" when the credentials
+// are taken from the default credential chain
+func getProfileName(stg settings) string {
+	if stg.awsProfileName == "" || stg.useEnvCreds {
+		return "-"
+	}
+
+	return stg.awsProfileName
+}
+
+// scanProfiles runs the scan with each AWS profile in order and merges the Lambda functions of all profiles.
+// The profiles that fail to load their config, to authenticate, or to list their functions are skipped with a warning.
+// It returns the application of the first scanned profile, which also holds the run state of the other profiles
+// for the summaries at the end of the run, or nil if none of the profiles could be scanned
+func scanProfiles(ctx context.Context, logger *zap.SugaredLogger, stg settings, profiles []string, filters scanFilters) (*application, []lambdaFunction) {
+	var mainApp *application
+	lambdaFunctionsList := []lambdaFunction{}
+
+	for _, profile := range profiles {
+		if ctx.Err() != nil {
+			logger.Warnw("the run was interrupted, skipping the remaining profiles",
+				zap.String("profile_name", profile),
+			)
+			break
+		}
+
+		app, profileList, err := scanProfile(ctx, logger.With(zap.String("profile_name", profile)), stg, profile, filters)
+		if err != nil {
+			logger.Warnw("skipping the profile",
+				zap.String("profile_name", profile),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		logger.Infow("scanned the profile",
+			zap.String("profile_name", profile),
+			zap.String("account_id", app.accountId),
+			zap.Int("function_count", len(profileList)),
+		)
+
+		if mainApp == nil {
+			mainApp = app
+		} else {
+			mainApp.mergeRunState(app)
+		}
+		lambdaFunctionsList = append(lambdaFunctionsList, profileList...)
+	}
+
+	return mainApp, lambdaFunctionsList
+}
+
+// scanProfile lists, filters, and resolves the Lambda functions with the credentials of the profile
+func scanProfile(ctx context.Context, logger *zap.SugaredLogger, stg settings, profile string, filters scanFilters) (*application, []lambdaFunction, error) {
+	stg.awsProfileName = profile
+
+	cfg, err := loadAWSConfig(ctx, logger, stg)
+	err = wrapSSOError(err, profile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error when loading aws config: %w", err)
+	}
+
+	initStartTime := time.Now()
+	app, err := initializeApplication(ctx, logger, cfg, stg)
+	err = wrapSSOError(err, profile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error when initializing application struct: %w", err)
+	}
+	app.recordPhase("initialization", initStartTime)
+
+	listStartTime := time.Now()
+	lambdaFunctionsList, err := app.listLambdaFunctions(ctx, nil)
+	if err != nil {
+		if ctx.Err() == nil {
+			return nil, nil, fmt.Errorf("error when listing lambda function details: %w", err)
+		}
+
+		logger.Warnw("listing lambda function details was interrupted, continuing with partial results",
+			zap.Int("function_count", len(lambdaFunctionsList)),
+			zap.Error(err),
+		)
+	}
+	app.recordPhase("listing", listStartTime)
+
+	lambdaFunctionsList = app.filterLambdaFunctions(ctx, lambdaFunctionsList, filters)
+
+	return app, app.resolveLambdaFunctions(ctx, lambdaFunctionsList), nil
+}
+
+// mergeRunState adds the API call and throttle counts, the phase durations, the errors, the region stats,
+// and the regions of the application of another profile, so that the summaries at the end of the run
+// cover all profiles. The durations of the phases with the same name are added up
+func (app *application) mergeRunState(other *application) {
+	app.apiCallCount.Add(other.apiCallCount.Load())
+	app.throttleCount.Add(other.throttleCount.Load())
+
+	for _, phase := range other.phaseDurations {
+		i := slices.IndexFunc(app.phaseDurations, func(p phaseDuration) bool {
+			return p.name == phase.name
+		})
+		if i < 0 {
+			app.phaseDurations = append(app.phaseDurations, phase)
+			continue
+		}
+		app.phaseDurations[i].duration += phase.duration
+	}
+
+	app.runErrorsMu.Lock()
+	app.runErrors = append(app.runErrors, other.getErrors()...)
+	app.runErrorsMu.Unlock()
+
+	for region, stats := range other.getRegionStats() {
+		app.regionStats.update(region, func(s *regionStats) {
+			s.functionCount += stats.functionCount
+			s.throttleCount += stats.throttleCount
+			s.errorCount += stats.errorCount
+			s.lastInvokeAgeTotal += stats.lastInvokeAgeTotal
+			s.lastInvokeAgeCount += stats.lastInvokeAgeCount
+		})
+	}
+
+	for _, region := range other.regions {
+		if !slices.Contains(app.regions, region) {
+			app.regions = append(app.regions, region)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestProfileColumnOnlyWithProfiles(t *testing.T) {
+	columns := getDefaultColumns(settings{})
+	if slices.Contains(columns, "Profile") || slices.Contains(columns, "AccountId") {
+		t.Errorf("default columns %q contain Profile or AccountId without -profiles", columns)
+	}
+
+	// the opt-in columns are added after the existing columns, so that the position of the existing columns doesn't change
+	profileColumns := getDefaultColumns(settings{profiles: "dev,prod"})
+	if !slices.Contains(profileColumns, "Profile") || !slices.Contains(profileColumns, "AccountId") {
+		t.Errorf("columns %q don't contain Profile and AccountId with -profiles", profileColumns)
+	}
+	if !slices.Equal(slices.DeleteFunc(slices.Clone(profileColumns), func(column string) bool {
+		return column == "Profile" || column == "AccountId"
+	}), columns) {
+		t.Errorf("columns with -profiles %q don't keep the order of the default columns %q", profileColumns, columns)
+	}
+}
+
+func TestGetProfileName(t *testing.T) {
+	tests := []struct {
+		name string
+		stg  settings
+		want string
+	}{
+		{name: "profile", stg: settings{awsProfileName: "dev"}, want: "dev"},
+		{name: "default credential chain", stg: settings{awsProfileName: ""}, want: "-"},
+		{name: "environment credentials", stg: settings{awsProfileName: "dev", useEnvCreds: true}, want: "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getProfileName(tt.stg); got != tt.want {
+				t.Errorf("getProfileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateProfilesOptions(t *testing.T) {
+	if err := validateProfilesOptions(settings{profiles: "dev,prod"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, stg := range []settings{
+		{profiles: "dev,prod", useEnvCreds: true},
+		{profiles: "dev,prod", assumeRoleArn: "arn:aws:iam::123456789012:role/lister"},
+		{profiles: "dev,prod", functionsFile: "functions.txt"},
+		{profiles: "dev,prod", stream: true},
+	} {
+		if err := validateProfilesOptions(stg); err == nil {
+			t.Errorf("validateProfilesOptions(%+v) = nil, want an error", stg)
+		}
+	}
+}
+
+func TestScanProfilesSkipsFailedProfiles(t *testing.T) {
+	setTestAWSEnvironment(t, "us-east-1")
+
+	// the profiles don't exist in the config file, so both are skipped instead of aborting the run
+	app, lambdaFunctionsList := scanProfiles(context.Background(), zap.NewNop().Sugar(), settings{}, []string{"missing-1", "missing-2"}, scanFilters{})
+	if app != nil || len(lambdaFunctionsList) != 0 {
+		t.Errorf("scanProfiles() = %v and %d functions, want nil and no function", app, len(lambdaFunctionsList))
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// reportLastInvokeProgress logs how many of the total functions have had their last invoke time resolved
+// every interval, until done is closed
+func (app *application) reportLastInvokeProgress(total int, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			app.logger.Infow("getting last invoke time in progress",
+				zap.Int64("resolved", app.lastInvokeProgress.Load()),
+				zap.Int("total", total),
+			)
+		case <-done:
+			return
+		}
+	}
+}
+
+// isTerminal checks whether the file is a terminal (TTY), e.g. to avoid printing progress
+// when the output is redirected to a file
+func isTerminal(f *os.File) bool {
+	fileInfo, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fileInfo.Mode()&os.ModeCharDevice != 0
+}
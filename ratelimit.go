@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"math"
+
+	"golang.org/x/time/rate"
+)
+
+// newRegionRateLimiters creates one rate limiter per region, allowing requestsPerSecond requests per second
+// with bursts of at most one second of requests. If requestsPerSecond is 0 or less, no limiter is created
+func newRegionRateLimiters(regions []string, requestsPerSecond float64) map[string]*rate.Limiter {
+	limiters := map[string]*rate.Limiter{}
+	if requestsPerSecond <= 0 {
+		return limiters
+	}
+
+	burst := int(math.Max(1, math.Ceil(requestsPerSecond)))
+	for _, region := range regions {
+		limiters[region] = rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+	}
+
+	return limiters
+}
+
+// waitForRateLimit blocks until the rate limiter of the region allows one more request,
+// so that the API calls of one region don't exceed the chosen rate. It returns an error if ctx is done first
+func (app *application) waitForRateLimit(ctx context.Context, region string) error {
+	limiter, ok := app.rateLimiters[region]
+	if !ok {
+		return nil
+	}
+
+	return limiter.Wait(ctx)
+}
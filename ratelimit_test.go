@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitForRateLimitBoundsCallRate(t *testing.T) {
+	const requestsPerSecond = 50
+	const calls = 75
+
+	app := newTestApplication()
+	app.rateLimiters = newRegionRateLimiters([]string{"us-east-1", "eu-west-1"}, requestsPerSecond)
+
+	// the first second of requests is allowed at once, and the remaining calls wait for the limiter
+	startTime := time.Now()
+	for range calls {
+		err := app.waitForRateLimit(context.Background(), "us-east-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	elapsed := time.Since(startTime)
+
+	minElapsed := time.Duration(calls-requestsPerSecond) * time.Second / requestsPerSecond
+	if elapsed < minElapsed*8/10 {
+		t.Errorf("%d calls took %v, want at least %v with %d requests per second", calls, elapsed, minElapsed, requestsPerSecond)
+	}
+
+	// each region has its own limiter, so the other region can still send its burst right away
+	startTime = time.Now()
+	for range requestsPerSecond {
+		err := app.waitForRateLimit(context.Background(), "eu-west-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(startTime); elapsed > 100*time.Millisecond {
+		t.Errorf("the burst of the other region took %v, want it to be sent right away", elapsed)
+	}
+}
+
+func TestWaitForRateLimitCancelled(t *testing.T) {
+	app := newTestApplication()
+	app.rateLimiters = newRegionRateLimiters([]string{"us-east-1"}, 1)
+
+	err := app.waitForRateLimit(context.Background(), "us-east-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := app.waitForRateLimit(ctx, "us-east-1"); err == nil {
+		t.Error("expected an error when the context is cancelled")
+	}
+}
+
+func TestNewRegionRateLimitersDisabled(t *testing.T) {
+	app := newTestApplication()
+	app.rateLimiters = newRegionRateLimiters([]string{"us-east-1"}, 0)
+
+	if len(app.rateLimiters) != 0 {
+		t.Fatalf("created %d limiters, want none", len(app.rateLimiters))
+	}
+	if err := app.waitForRateLimit(context.Background(), "us-east-1"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
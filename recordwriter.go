@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// recordWriter writes the title row and the Lambda functions one by one in an output format.
+// Close must be called after the last record, so that the buffered output is written
+type recordWriter interface {
+	WriteHeader(titles []string) error
+	WriteRecord(lambdaDetails lambdaFunction) error
+	Close() error
+}
+
+// newRecordWriter returns the record writer of the chosen output format, which writes to w
+func newRecordWriter(w io.Writer, opts outputOptions) recordWriter {
+	switch opts.format {
+	case outputFormatJSON:
+		return &jsonWriter{w: w, columnsType: getJSONColumnsType(opts)}
+	case outputFormatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w), columnsType: getJSONColumnsType(opts)}
+	case outputFormatMarkdown:
+		return &markdownWriter{w: w, opts: opts}
+	case outputFormatHTML:
+		return &bufferedWriter{w: w, opts: opts, write: writeHTML}
+	case outputFormatXLSX:
+		return &bufferedWriter{w: w, opts: opts, write: writeXLSX}
+	default:
+		return newCSVWriter(w, opts)
+	}
+}
+
+// getJSONColumnsType returns the struct type of the JSON columns, or nil if all fields are written
+func getJSONColumnsType(opts outputOptions) reflect.Type {
+	if len(opts.jsonColumns) == 0 {
+		return nil
+	}
+
+	return newColumnsType(opts.jsonColumns)
+}
+
+// getJSONValue returns the value written in the JSON output for the Lambda function, with only the JSON columns
+// when columnsType is not nil
+func getJSONValue(lambdaDetails lambdaFunction, columnsType reflect.Type) any {
+	if columnsType == nil {
+		return lambdaDetails
+	}
+
+	return lambdaDetails.selectColumns(columnsType)
+}
+
+// csvWriter writes one row per Lambda function with only the chosen columns
+type csvWriter struct {
+	cw   *csv.Writer
+	opts outputOptions
+}
+
+// newCSVWriter creates the csvWriter with the chosen delimiter
+func newCSVWriter(w io.Writer, opts outputOptions) *csvWriter {
+	cw := csv.NewWriter(w)
+	if opts.delimiter != 0 {
+		cw.Comma = opts.delimiter
+	}
+
+	return &csvWriter{cw: cw, opts: opts}
+}
+
+func (c *csvWriter) WriteHeader(titles []string) error {
+	return c.cw.Write(titles)
+}
+
+func (c *csvWriter) WriteRecord(lambdaDetails lambdaFunction) error {
+	if c.opts.truncateDescription > 0 {
+		lambdaDetails.Description = truncateDescription(lambdaDetails.Description, c.opts.truncateDescription)
+	}
+
+	return c.cw.Write(lambdaDetails.getRecordFields(c.opts.columns))
+}
+
+func (c *csvWriter) Close() error {
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+// jsonWriter writes all Lambda functions as a single pretty-printed JSON array, with an empty result written as [].
+// The title row is not written, since the keys of each object are the column names
+type jsonWriter struct {
+	w           io.Writer
+	columnsType reflect.Type
+	recordCount int
+}
+
+func (j *jsonWriter) WriteHeader(titles []string) error {
+	return nil
+}
+
+func (j *jsonWriter) WriteRecord(lambdaDetails lambdaFunction) error {
+	b, err := json.MarshalIndent(getJSONValue(lambdaDetails, j.columnsType), "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n  "
+	if j.recordCount == 0 {
+		separator = "[\n  "
+	}
+	j.recordCount++
+
+	_, err = fmt.Fprintf(j.w, "%s%s", separator, b)
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	closing := "\n]\n"
+	if j.recordCount == 0 {
+		closing = "[]\n"
+	}
+
+	_, err := io.WriteString(j.w, closing)
+	return err
+}
+
+// jsonlWriter writes one JSON object per line for each Lambda function
+type jsonlWriter struct {
+	enc         *json.Encoder
+	columnsType reflect.Type
+}
+
+func (j *jsonlWriter) WriteHeader(titles []string) error {
+	return nil
+}
+
+func (j *jsonlWriter) WriteRecord(lambdaDetails lambdaFunction) error {
+	return j.enc.Encode(getJSONValue(lambdaDetails, j.columnsType))
+}
+
+func (j *jsonlWriter) Close() error {
+	return nil
+}
+
+// markdownWriter writes a GitHub-flavored Markdown table with the chosen columns
+type markdownWriter struct {
+	w    io.Writer
+	opts outputOptions
+}
+
+// WriteHeader writes the title row followed by the separator row
+func (m *markdownWriter) WriteHeader(titles []string) error {
+	separators := make([]string, len(titles))
+	for i := range separators {
+		separators[i] = "---"
+	}
+
+	_, err := fmt.Fprintf(m.w, "%s\n%s\n", formatMarkdownRow(titles), formatMarkdownRow(separators))
+	return err
+}
+
+func (m *markdownWriter) WriteRecord(lambdaDetails lambdaFunction) error {
+	if m.opts.truncateDescription > 0 {
+		lambdaDetails.Description = truncateDescription(lambdaDetails.Description, m.opts.truncateDescription)
+	}
+
+	_, err := fmt.Fprintln(m.w, formatMarkdownRow(lambdaDetails.getRecordFields(m.opts.columns)))
+	return err
+}
+
+func (m *markdownWriter) Close() error {
+	return nil
+}
+
+// bufferedWriter keeps all Lambda functions and writes them on Close, for the output formats
+// that are written as a whole document, such as html and xlsx. The document has its own title row
+type bufferedWriter struct {
+	w       io.Writer
+	opts    outputOptions
+	write   func(w io.Writer, opts outputOptions, lambdaFunctionsList []lambdaFunction) error
+	records []lambdaFunction
+}
+
+func (b *bufferedWriter) WriteHeader(titles []string) error {
+	return nil
+}
+
+func (b *bufferedWriter) WriteRecord(lambdaDetails lambdaFunction) error {
+	b.records = append(b.records, lambdaDetails)
+	return nil
+}
+
+func (b *bufferedWriter) Close() error {
+	return b.write(b.w, b.opts, b.records)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteOutputFormats(t *testing.T) {
+	columns := []string{"Name", "Region", "Description"}
+	wantRows := [][]string{
+		{"Function Name", "Region", "Function Description"},
+		{"alpha", "us-east-1", "first function"},
+		{"bravo", "eu-west-1", "second | function"},
+	}
+
+	// readRows reads the title row and the records back from the output of each format
+	tests := []struct {
+		format   string
+		readRows func(t *testing.T, output []byte) [][]string
+	}{
+		{
+			format: outputFormatCSV,
+			readRows: func(t *testing.T, output []byte) [][]string {
+				rows, err := csv.NewReader(bytes.NewReader(output)).ReadAll()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return rows
+			},
+		},
+		{
+			format: outputFormatJSON,
+			readRows: func(t *testing.T, output []byte) [][]string {
+				var functions []map[string]any
+				err := json.Unmarshal(output, &functions)
+				if err != nil {
+					t.Fatalf("the output is not a JSON array: %v", err)
+				}
+				return jsonRows(functions)
+			},
+		},
+		{
+			format: outputFormatJSONL,
+			readRows: func(t *testing.T, output []byte) [][]string {
+				var functions []map[string]any
+				scanner := bufio.NewScanner(bytes.NewReader(output))
+				for scanner.Scan() {
+					var function map[string]any
+					err := json.Unmarshal(scanner.Bytes(), &function)
+					if err != nil {
+						t.Fatalf("the line %q is not a JSON object: %v", scanner.Text(), err)
+					}
+					functions = append(functions, function)
+				}
+				return jsonRows(functions)
+			},
+		},
+		{
+			format: outputFormatMarkdown,
+			readRows: func(t *testing.T, output []byte) [][]string {
+				rows := [][]string{}
+				for i, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+					// the second line is the separator row
+					if i == 1 {
+						continue
+					}
+					line = strings.ReplaceAll(strings.Trim(line, "| "), `\|`, "\x00")
+					cells := strings.Split(line, " | ")
+					for j := range cells {
+						cells[j] = strings.ReplaceAll(cells[j], "\x00", "|")
+					}
+					rows = append(rows, cells)
+				}
+				return rows
+			},
+		},
+		{
+			format: outputFormatHTML,
+			readRows: func(t *testing.T, output []byte) [][]string {
+				rows := [][]string{}
+				for _, line := range strings.Split(string(output), "\n") {
+					if !strings.HasPrefix(line, "<tr>") {
+						continue
+					}
+					line = strings.TrimSuffix(strings.TrimPrefix(line, "<tr>"), "</tr>")
+					line = strings.NewReplacer("<th>", "", "<td>", "", "</td>", "\x00", "</th>", "\x00").Replace(line)
+					rows = append(rows, strings.Split(strings.TrimSuffix(line, "\x00"), "\x00"))
+				}
+				return rows
+			},
+		},
+		{
+			format: outputFormatXLSX,
+			readRows: func(t *testing.T, output []byte) [][]string {
+				f, err := excelize.OpenReader(bytes.NewReader(output))
+				if err != nil {
+					t.Fatalf("the output is not an xlsx file: %v", err)
+				}
+				defer f.Close()
+
+				rows, err := f.GetRows(xlsxSheetName)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return rows
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := outputOptions{format: tt.format, columns: columns, jsonColumns: columns}
+
+			err := writeOutput(&buf, opts, newTestFunctions())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rows := tt.readRows(t, buf.Bytes())
+			if len(rows) != len(wantRows) {
+				t.Fatalf("rows = %q, want %q", rows, wantRows)
+			}
+			for i := range wantRows {
+				if !slices.Equal(rows[i], wantRows[i]) {
+					t.Errorf("row %d = %q, want %q", i, rows[i], wantRows[i])
+				}
+			}
+		})
+	}
+}
+
+// jsonRows returns the rows of the JSON objects of the test functions, with the titles as the first row,
+// so that the JSON output can be compared with the other formats
+func jsonRows(functions []map[string]any) [][]string {
+	rows := [][]string{{"Function Name", "Region", "Function Description"}}
+	for _, function := range functions {
+		row := []string{}
+		for _, key := range []string{"name", "region", "description"} {
+			value, _ := function[key].(string)
+			row = append(row, value)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func TestWriteOutputHTMLEscapesValues(t *testing.T) {
+	var buf bytes.Buffer
+	lambdaFunctionsList := []lambdaFunction{{Name: "alpha", Description: "<script>alert(1)</script>"}}
+
+	err := writeOutput(&buf, outputOptions{format: outputFormatHTML, columns: []string{"Name", "Description"}}, lambdaFunctionsList)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(buf.String(), "<script>alert") || !strings.Contains(buf.String(), "&lt;script&gt;alert(1)&lt;/script&gt;") {
+		t.Errorf("the description is not escaped in the HTML output:\n%s", buf.String())
+	}
+}
+
+func TestWriteOutputJSONEmpty(t *testing.T) {
+	for _, format := range []string{outputFormatJSON, outputFormatJSONL} {
+		var buf bytes.Buffer
+		err := writeOutput(&buf, outputOptions{format: format}, []lambdaFunction{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := map[string]string{outputFormatJSON: "[]\n", outputFormatJSONL: ""}[format]
+		if buf.String() != want {
+			t.Errorf("%s output of an empty result = %q, want %q", format, buf.String(), want)
+		}
+	}
+}
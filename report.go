@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// countFunctionsPerRegion returns the number of Lambda functions in each region.
+// Regions without any function are included with a count of 0
+func countFunctionsPerRegion(lambdaFunctionsList []lambdaFunction, regions []string) map[string]int {
+	counts := map[string]int{}
+	for _, region := range regions {
+		counts[region] = 0
+	}
+
+	for _, lambdaDetails := range lambdaFunctionsList {
+		counts[lambdaDetails.Region]++
+	}
+
+	return counts
+}
+
+// printRegionSummary writes the number of Lambda functions in each region as a table to w,
+// in the same order as the regions
+func printRegionSummary(w io.Writer, lambdaFunctionsList []lambdaFunction, regions []string) error {
+	counts := countFunctionsPerRegion(lambdaFunctionsList, regions)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REGION\tFUNCTIONS")
+	for _, region := range regions {
+		fmt.Fprintf(tw, "%s\t%d\n", region, counts[region])
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\n", len(lambdaFunctionsList))
+
+	return tw.Flush()
+}
+
+// runResult is the summary of the run that is printed in quiet mode,
+// so that the program can be composed in scripts and pipelines
+type runResult struct {
+	TotalFunctions  int            `json:"total_functions"`
+	RegionCounts    map[string]int `json:"region_counts"`
+	ErrorCount      int            `json:"error_count"`
+	OutputFile      string         `json:"output_file"`
+	DurationSeconds float64        `json:"duration_seconds"`
+}
+
+// printRunResult writes the run result as a single line JSON object to w
+func printRunResult(w io.Writer, result runResult) error {
+	return json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatch/types"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRunMetricsNamespace = "AlliLister"
+	accountIdDimension         = "AccountId"
+
+	// runMetricsTimeout limits how long putting the run metrics can take, since it happens after the run timeout
+	runMetricsTimeout = 30 * time.Second
+)
+
+// putRunMetrics puts the number of scanned functions, the number of errors, and the duration of the run
+// as custom CloudWatch metrics in the namespace, with the account ID as dimension, so that alarms can be set
+// on failed scans
+func putRunMetrics(ctx context.Context, cwClient metricDataPutter, namespace string, accountId string, result runResult) error {
+	dimensions := []types.Dimension{
+		{
+			Name:  aws.String(accountIdDimension),
+			Value: aws.String(accountId),
+		},
+	}
+	timestamp := time.Now()
+
+	_, err := cwClient.PutMetricData(ctx, &cloudwatch.PutMetricDataInput{
+		Namespace: aws.String(namespace),
+		MetricData: []types.MetricDatum{
+			{
+				MetricName: aws.String("FunctionsScanned"),
+				Dimensions: dimensions,
+				Timestamp:  aws.Time(timestamp),
+				Unit:       types.StandardUnitCount,
+				Value:      aws.Float64(float64(result.TotalFunctions)),
+			},
+			{
+				MetricName: aws.String("Errors"),
+				Dimensions: dimensions,
+				Timestamp:  aws.Time(timestamp),
+				Unit:       types.StandardUnitCount,
+				Value:      aws.Float64(float64(result.ErrorCount)),
+			},
+			{
+				MetricName: aws.String("DurationSeconds"),
+				Dimensions: dimensions,
+				Timestamp:  aws.Time(timestamp),
+				Unit:       types.StandardUnitSeconds,
+				Value:      aws.Float64(result.DurationSeconds),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error when putting the run metrics: %w", err)
+	}
+
+	return nil
+}
+
+// emitRunMetrics puts the run metrics in the region chosen with -run-metrics-region, or in the default region.
+// A failure is only logged, since the output has already been written
+func (app *application) emitRunMetrics(result runResult) {
+	region := app.stg.runMetricsRegion
+	if region == "" {
+		region = app.cfg.Region
+	}
+
+	cwClient := cloudwatch.NewFromConfig(*app.cfg, func(o *cloudwatch.Options) {
+		o.Region = region
+		setEndpointOptions(app.stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), runMetricsTimeout)
+	defer cancel()
+
+	err := putRunMetrics(ctx, cwClient, app.stg.runMetricsNamespace, app.accountId, result)
+	if err != nil {
+		app.logger.Errorw("error when emitting the run metrics",
+			zap.String("region", region),
+			zap.String("namespace", app.stg.runMetricsNamespace),
+			zap.Error(err),
+		)
+		return
+	}
+
+	app.logger.Infow("emitted the run metrics",
+		zap.String("region", region),
+		zap.String("namespace", app.stg.runMetricsNamespace),
+	)
+}
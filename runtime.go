@@ -0,0 +1,65 @@
+package main
+
+const (
+	runtimeStatusSupported   = "supported"
+	runtimeStatusDeprecated  = "deprecated"
+	runtimeStatusDeprecating = "deprecating"
+)
+
+// runtimeStatuses contains the runtimes that are deprecated, or will be deprecated soon, by AWS.
+// The runtimes that are not listed are considered supported.
+// Update this map following https://docs.aws.amazon.com/lambda/latest/dg/lambda-runtimes.html
+var runtimeStatuses = map[string]string{
+	"nodejs":        runtimeStatusDeprecated,
+	"nodejs4.3":     runtimeStatusDeprecated,
+	"nodejs6.10":    runtimeStatusDeprecated,
+	"nodejs8.10":    runtimeStatusDeprecated,
+	"nodejs10.x":    runtimeStatusDeprecated,
+	"nodejs12.x":    runtimeStatusDeprecated,
+	"nodejs14.x":    runtimeStatusDeprecated,
+	"nodejs16.x":    runtimeStatusDeprecated,
+	"nodejs18.x":    runtimeStatusDeprecated,
+	"nodejs20.x":    runtimeStatusDeprecating,
+	"python2.7":     runtimeStatusDeprecated,
+	"python3.6":     runtimeStatusDeprecated,
+	"python3.7":     runtimeStatusDeprecated,
+	"python3.8":     runtimeStatusDeprecated,
+	"python3.9":     runtimeStatusDeprecated,
+	"python3.10":    runtimeStatusDeprecating,
+	"ruby2.5":       runtimeStatusDeprecated,
+	"ruby2.7":       runtimeStatusDeprecated,
+	"ruby3.2":       runtimeStatusDeprecated,
+	"java8":         runtimeStatusDeprecated,
+	"java8.al2":     runtimeStatusDeprecating,
+	"java11":        runtimeStatusDeprecating,
+	"dotnetcore1.0": runtimeStatusDeprecated,
+	"dotnetcore2.0": runtimeStatusDeprecated,
+	"dotnetcore2.1": runtimeStatusDeprecated,
+	"dotnetcore3.1": runtimeStatusDeprecated,
+	"dotnet5.0":     runtimeStatusDeprecated,
+	"dotnet6":       runtimeStatusDeprecated,
+	"dotnet7":       runtimeStatusDeprecated,
+	"go1.x":         runtimeStatusDeprecated,
+	"provided":      runtimeStatusDeprecated,
+	"provided.al2":  runtimeStatusDeprecating,
+}
+
+// getRuntimeStatus returns whether the runtime is supported, deprecated, or deprecating.
+// Functions without a runtime, e.g. container image functions, have "-" status
+func getRuntimeStatus(runtime string) string {
+	if runtime == "" {
+		return "-"
+	}
+
+	status, ok := runtimeStatuses[runtime]
+	if !ok {
+		return runtimeStatusSupported
+	}
+
+	return status
+}
+
+// isDeprecatedRuntimeStatus checks whether the runtime is deprecated or will be deprecated soon
+func isDeprecatedRuntimeStatus(status string) bool {
+	return status == runtimeStatusDeprecated || status == runtimeStatusDeprecating
+}
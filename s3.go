@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"go.uber.org/zap"
+)
+
+const s3URIPrefix = "s3://"
+
+// isS3URI checks whether the output file name is an S3 URI, e.g. s3://bucket/key
+func isS3URI(fileName string) bool {
+	return strings.HasPrefix(fileName, s3URIPrefix)
+}
+
+// parseS3URI splits an S3 URI with format s3://bucket/key into the bucket name and the object key
+func parseS3URI(uri string) (string, string, error) {
+	bucket, key, found := strings.Cut(strings.TrimPrefix(uri, s3URIPrefix), "/")
+	if !found || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid S3 URI %q, the format must be s3://bucket/key", uri)
+	}
+
+	return bucket, key, nil
+}
+
+// writeOutputToS3 buffers the output in memory and uploads it to the S3 object specified by uri.
+// If s3Region is empty, the region of the bucket is detected automatically
+func (app *application) writeOutputToS3(ctx context.Context, uri string, s3Region string, opts outputOptions, lambdaFunctionsList []lambdaFunction) error {
+	bucket, key, err := parseS3URI(uri)
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	err = writeEncodedOutput(buf, opts, lambdaFunctionsList)
+	if err != nil {
+		return err
+	}
+
+	s3Client := s3.NewFromConfig(*app.cfg, app.setS3Options(""))
+	if s3Region == "" {
+		app.logger.Debugw("detecting the region of the S3 bucket",
+			zap.String("bucket", bucket),
+		)
+
+		s3Region, err = manager.GetBucketRegion(ctx, s3Client, bucket)
+		if err != nil {
+			return fmt.Errorf("error when detecting the region of bucket %q: %w", bucket, err)
+		}
+	}
+
+	s3Client = s3.NewFromConfig(*app.cfg, app.setS3Options(s3Region))
+
+	app.logger.Debugw("uploading the output to S3",
+		zap.String("bucket", bucket),
+		zap.String("key", key),
+		zap.String("region", s3Region),
+	)
+
+	uploader := manager.NewUploader(s3Client)
+	_, err = uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   buf,
+	})
+	if err != nil {
+		return fmt.Errorf("error when uploading the output to %q: %w", uri, err)
+	}
+
+	return nil
+}
+
+// setS3Options returns the options of the S3 client, which uses the same endpoint settings as the other clients.
+// The bucket is addressed in the path with a custom endpoint, since e.g. LocalStack at http://localhost:4566
+// can't resolve the bucket as a subdomain. The region of the config is kept if region is empty
+func (app *application) setS3Options(region string) func(*s3.Options) {
+	return func(o *s3.Options) {
+		if region != "" {
+			o.Region = region
+		}
+		setEndpointOptions(app.stg, &o.BaseEndpoint, &o.EndpointOptions.UseFIPSEndpoint, &o.EndpointOptions.UseDualStackEndpoint)
+		if app.stg.endpointURL != "" {
+			o.UsePathStyle = true
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// scanFilters contains the parsed filters that are applied to the listed Lambda functions
+type scanFilters struct {
+	nameFilter    *regexp.Regexp
+	modifiedAfter time.Time
+	tagFilters    []tagFilter
+
+	// seed is the seed of the random sample picked with -sample, which is already chosen if -seed is not set
+	seed uint64
+}
+
+// listLambdaFunctions lists the Lambda functions of the chosen regions, or gets the functions of the functions file
+// if it is set. If listing a region fails, the functions listed so far are returned together with the error
+func (app *application) listLambdaFunctions(ctx context.Context, functionEntries []functionEntry) ([]lambdaFunction, error) {
+	if app.stg.functionsFile != "" {
+		return app.getLambdaFunctionsFromFile(ctx, functionEntries, app.stg.getListWorkers()), nil
+	}
+
+	return app.getAllLambdaFunctionsDetails(ctx, app.stg.getListWorkers())
+}
+
+// filterLambdaFunctions applies the filters that don't depend on the last invoke time,
+// then picks the random sample if -sample is set. With -tag-filter, the tags of the functions that match
+// the other filters are listed first, so that the tags are only listed once and only for these functions
+func (app *application) filterLambdaFunctions(ctx context.Context, lambdaFunctionsList []lambdaFunction, filters scanFilters) []lambdaFunction {
+	lambdaFunctionsList = filterByRuntime(lambdaFunctionsList, app.stg.runtimes)
+	lambdaFunctionsList = filterByName(lambdaFunctionsList, filters.nameFilter)
+	lambdaFunctionsList = filterDeprecatedRuntimes(lambdaFunctionsList, app.stg.onlyDeprecated)
+
+	lambdaFunctionsList, unparseableNames := filterByModifiedAfter(lambdaFunctionsList, filters.modifiedAfter)
+	for _, name := range unparseableNames {
+		app.logger.Warnw("can't parse the last modified time of the lambda function, keeping it in the output",
+			zap.String("function_name", name),
+		)
+	}
+
+	app.logger.Debugw("filtered lambda functions",
+		zap.Strings("runtimes", app.stg.runtimes),
+		zap.String("name_filter", app.stg.nameFilter),
+		zap.Bool("only_deprecated", app.stg.onlyDeprecated),
+		zap.String("modified_after", app.stg.modifiedAfter),
+		zap.Int("function_count", len(lambdaFunctionsList)),
+	)
+
+	if len(filters.tagFilters) > 0 {
+		tagJobs := app.generateJobs(ctx, lambdaFunctionsList)
+		app.getAllLambdaFunctionsTags(ctx, lambdaFunctionsList, tagJobs, app.stg.maxWorkers)
+
+		functionCount := len(lambdaFunctionsList)
+		lambdaFunctionsList = filterByTags(lambdaFunctionsList, filters.tagFilters)
+		app.logger.Infow("filtered lambda functions by tags",
+			zap.Strings("tag_filters", app.stg.tagFilters),
+			zap.Int("function_count", functionCount),
+			zap.Int("matched_function_count", len(lambdaFunctionsList)),
+		)
+	}
+
+	if app.stg.sample > 0 {
+		functionCount := len(lambdaFunctionsList)
+		lambdaFunctionsList = sampleLambdaFunctions(lambdaFunctionsList, app.stg.sample, filters.seed)
+		app.logger.Infow("sampled lambda functions",
+			zap.Int("function_count", functionCount),
+			zap.Int("sampled_function_count", len(lambdaFunctionsList)),
+			zap.Uint64("seed", filters.seed),
+		)
+	}
+
+	return lambdaFunctionsList
+}
+
+// resolveLambdaFunctions gets the last invoke time of the Lambda functions, applies the filters that depend on it,
+// and gets the details chosen with the -with-* flags
+func (app *application) resolveLambdaFunctions(ctx context.Context, lambdaFunctionsList []lambdaFunction) []lambdaFunction {
+	lastInvokeStartTime := time.Now()
+	if app.stg.skipLastInvoke {
+		app.logger.Info("skipping the last invoke time")
+		for i := range lambdaFunctionsList {
+			lambdaFunctionsList[i].LastInvoked = lastInvokedSkipped
+		}
+	} else if app.stg.includeVersions {
+		// all versions of a function write to the same log group, so the last invoke time is only resolved once per function
+		latestList := getLatestVersions(lambdaFunctionsList)
+		jobs := app.generateJobs(ctx, latestList)
+		app.getAllLambdaFunctionsLastInvokeTime(ctx, latestList, jobs, app.stg.getLogsWorkers())
+		copyLastInvokedToVersions(latestList, lambdaFunctionsList)
+	} else {
+		jobs := app.generateJobs(ctx, lambdaFunctionsList)
+		app.getAllLambdaFunctionsLastInvokeTime(ctx, lambdaFunctionsList, jobs, app.stg.getLogsWorkers())
+	}
+	app.recordPhase("last_invoke", lastInvokeStartTime)
+
+	if !app.stg.includeNeverInvoked {
+		functionCount := len(lambdaFunctionsList)
+		lambdaFunctionsList = filterNeverInvoked(lambdaFunctionsList)
+		app.logger.Infow("dropped never invoked lambda functions",
+			zap.Int("dropped_function_count", functionCount-len(lambdaFunctionsList)),
+		)
+	}
+
+	if app.stg.maxAgeDays > 0 {
+		lambdaFunctionsList = filterByMaxAge(lambdaFunctionsList, app.stg.maxAgeDays, time.Now())
+		app.logger.Infow("filtered stale lambda functions",
+			zap.Int("max_age_days", app.stg.maxAgeDays),
+			zap.Int("stale_function_count", len(lambdaFunctionsList)),
+		)
+	}
+
+	enrichmentStartTime := time.Now()
+	if app.stg.withAliases {
+		app.getAllLambdaFunctionsAliases(ctx, lambdaFunctionsList, app.stg.maxWorkers)
+	}
+
+	if app.stg.withRolePolicies {
+		rolePolicyJobs := app.generateJobs(ctx, lambdaFunctionsList)
+		app.getAllLambdaFunctionsRolePolicies(ctx, lambdaFunctionsList, rolePolicyJobs, app.stg.maxWorkers)
+	}
+
+	if app.stg.withLogRetention {
+		logRetentionJobs := app.generateJobs(ctx, lambdaFunctionsList)
+		app.getAllLambdaFunctionsLogRetention(ctx, lambdaFunctionsList, logRetentionJobs, app.stg.maxWorkers)
+	}
+
+	if app.stg.detailed {
+		detailedJobs := app.generateJobs(ctx, lambdaFunctionsList)
+		app.getAllLambdaFunctionsDetailedConfig(ctx, lambdaFunctionsList, detailedJobs, app.stg.maxWorkers)
+	} else if hasImagePackageType(lambdaFunctionsList) {
+		// the image URI is already written by the detailed configuration
+		imageUriJobs := app.generateJobs(ctx, lambdaFunctionsList)
+		app.getAllLambdaFunctionsImageUri(ctx, lambdaFunctionsList, imageUriJobs, app.stg.maxWorkers)
+	}
+
+	// the tags are already listed by filterLambdaFunctions with -tag-filter
+	if app.stg.withTags && len(app.stg.tagFilters) == 0 {
+		tagJobs := app.generateJobs(ctx, lambdaFunctionsList)
+		app.getAllLambdaFunctionsTags(ctx, lambdaFunctionsList, tagJobs, app.stg.maxWorkers)
+	}
+
+	if app.stg.withDestinations {
+		destinationJobs := app.generateJobs(ctx, lambdaFunctionsList)
+		app.getAllLambdaFunctionsDestinations(ctx, lambdaFunctionsList, destinationJobs, app.stg.maxWorkers)
+	}
+
+	if app.stg.withConcurrency {
+		concurrencyJobs := app.generateJobs(ctx, lambdaFunctionsList)
+		app.getAllLambdaFunctionsConcurrency(ctx, lambdaFunctionsList, concurrencyJobs, app.stg.maxWorkers)
+	}
+
+	if app.stg.withCostEstimate {
+		costEstimateJobs := app.generateJobs(ctx, lambdaFunctionsList)
+		app.getAllLambdaFunctionsCostEstimate(ctx, lambdaFunctionsList, costEstimateJobs, app.stg.maxWorkers)
+	}
+
+	app.recordPhase("enrichment", enrichmentStartTime)
+
+	return lambdaFunctionsList
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestResolveLambdaFunctionsSkipLastInvoke(t *testing.T) {
+	client := &fakeCWLogsClient{}
+
+	app := newTestApplication()
+	app.stg.skipLastInvoke = true
+	app.stg.maxWorkers = 5
+	app.cwLogsClients = map[string]cwLogsAPI{"us-east-1": client}
+
+	lambdaFunctionsList := []lambdaFunction{}
+	for _, functionDetail := range functionConfigurations("function", 3) {
+		lambdaFunctionsList = append(lambdaFunctionsList, newLambdaFunction(functionDetail, "us-east-1"))
+	}
+
+	// the skipped functions are kept even without -include-never-invoked, since they are not known to be never invoked
+	lambdaFunctionsList = app.resolveLambdaFunctions(context.Background(), lambdaFunctionsList)
+	if len(lambdaFunctionsList) != 3 {
+		t.Fatalf("got %d functions, want 3", len(lambdaFunctionsList))
+	}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		if lambdaDetails.LastInvoked != lastInvokedSkipped {
+			t.Errorf("last invoke time of %s = %q, want %q", lambdaDetails.Name, lambdaDetails.LastInvoked, lastInvokedSkipped)
+		}
+	}
+
+	if len(client.inputs) != 0 {
+		t.Errorf("DescribeLogStreams is called %d times, want 0", len(client.inputs))
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// lastModifiedTimeFormat is the format of the LastModified field returned by the Lambda API
+const lastModifiedTimeFormat = "2006-01-02T15:04:05.000-0700"
+
+// sortableColumns are the struct field names of lambdaFunction that can be used to sort the output
+var sortableColumns = []string{"Name", "Region", "LastModified", "LastInvoked", "CodeSize"}
+
+// validateSortBy makes sure that the output can be sorted by the chosen column.
+// An empty sortBy is valid, and means that the output is not sorted
+func validateSortBy(sortBy string) error {
+	if sortBy == "" || slices.Contains(sortableColumns, sortBy) {
+		return nil
+	}
+
+	return fmt.Errorf("unsupported sort column %q, valid values are %s", sortBy, strings.Join(sortableColumns, ", "))
+}
+
+// sortLambdaFunctions sorts the Lambda functions in place by the chosen column, in ascending order
+// unless descending is set. Timestamp columns are sorted chronologically, and functions that were never
+// invoked ("-") or whose timestamp can't be parsed are always sorted to the end
+func sortLambdaFunctions(lambdaFunctionsList []lambdaFunction, sortBy string, descending bool) {
+	var less func(a, b lambdaFunction) bool
+
+	switch sortBy {
+	case "Name":
+		less = func(a, b lambdaFunction) bool { return a.Name < b.Name }
+	case "Region":
+		less = func(a, b lambdaFunction) bool { return a.Region < b.Region }
+	case "CodeSize":
+		less = func(a, b lambdaFunction) bool { return a.CodeSize < b.CodeSize }
+	case "LastModified":
+		sortByTime(lambdaFunctionsList, descending, func(l lambdaFunction) (time.Time, error) {
+			return time.Parse(lastModifiedTimeFormat, l.LastModified)
+		})
+		return
+	case "LastInvoked":
+		sortByTime(lambdaFunctionsList, descending, func(l lambdaFunction) (time.Time, error) {
+			return time.Parse(lastInvokedTimeFormat, l.LastInvoked)
+		})
+		return
+	default:
+		return
+	}
+
+	sort.SliceStable(lambdaFunctionsList, func(i, j int) bool {
+		if descending {
+			return less(lambdaFunctionsList[j], lambdaFunctionsList[i])
+		}
+		return less(lambdaFunctionsList[i], lambdaFunctionsList[j])
+	})
+}
+
+// sortByTime sorts the Lambda functions in place chronologically by the timestamp returned by parseTime.
+// Functions whose timestamp can't be parsed are sorted to the end regardless of the order
+func sortByTime(lambdaFunctionsList []lambdaFunction, descending bool, parseTime func(lambdaFunction) (time.Time, error)) {
+	sort.SliceStable(lambdaFunctionsList, func(i, j int) bool {
+		ti, errI := parseTime(lambdaFunctionsList[i])
+		tj, errJ := parseTime(lambdaFunctionsList[j])
+
+		if errI != nil || errJ != nil {
+			return errI == nil && errJ != nil
+		}
+
+		if descending {
+			return ti.After(tj)
+		}
+		return ti.Before(tj)
+	})
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSortLambdaFunctions(t *testing.T) {
+	newList := func() []lambdaFunction {
+		return []lambdaFunction{
+			{Name: "bravo", Region: "us-east-1", LastModified: "2024-03-01T00:00:00.000+0000", LastInvoked: "2024-05-02T00:00:00+00:00", CodeSize: 300},
+			{Name: "alpha", Region: "eu-west-1", LastModified: "2024-01-01T00:00:00.000+0000", LastInvoked: "-", CodeSize: 100},
+			{Name: "delta", Region: "ap-southeast-1", LastModified: "invalid", LastInvoked: "2024-05-01T00:00:00+00:00", CodeSize: 400},
+			{Name: "charlie", Region: "ca-central-1", LastModified: "2024-02-01T00:00:00.000+0000", LastInvoked: "2024-05-03T00:00:00+00:00", CodeSize: 200},
+		}
+	}
+
+	tests := []struct {
+		sortBy     string
+		descending bool
+		wantNames  []string
+	}{
+		{sortBy: "", wantNames: []string{"bravo", "alpha", "delta", "charlie"}},
+		{sortBy: "Name", wantNames: []string{"alpha", "bravo", "charlie", "delta"}},
+		{sortBy: "Name", descending: true, wantNames: []string{"delta", "charlie", "bravo", "alpha"}},
+		{sortBy: "Region", wantNames: []string{"delta", "charlie", "alpha", "bravo"}},
+		{sortBy: "Region", descending: true, wantNames: []string{"bravo", "alpha", "charlie", "delta"}},
+		{sortBy: "CodeSize", wantNames: []string{"alpha", "charlie", "bravo", "delta"}},
+		{sortBy: "CodeSize", descending: true, wantNames: []string{"delta", "bravo", "charlie", "alpha"}},
+		// the timestamps that can't be parsed are always at the end
+		{sortBy: "LastModified", wantNames: []string{"alpha", "charlie", "bravo", "delta"}},
+		{sortBy: "LastModified", descending: true, wantNames: []string{"bravo", "charlie", "alpha", "delta"}},
+		{sortBy: "LastInvoked", wantNames: []string{"delta", "bravo", "charlie", "alpha"}},
+		{sortBy: "LastInvoked", descending: true, wantNames: []string{"charlie", "bravo", "delta", "alpha"}},
+	}
+
+	for _, tt := range tests {
+		name := tt.sortBy
+		if tt.descending {
+			name += " descending"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			lambdaFunctionsList := newList()
+			sortLambdaFunctions(lambdaFunctionsList, tt.sortBy, tt.descending)
+
+			names := []string{}
+			for _, lambdaDetails := range lambdaFunctionsList {
+				names = append(names, lambdaDetails.Name)
+			}
+			if !slices.Equal(names, tt.wantNames) {
+				t.Errorf("names = %q, want %q", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestValidateSortBy(t *testing.T) {
+	for _, sortBy := range append([]string{""}, sortableColumns...) {
+		if err := validateSortBy(sortBy); err != nil {
+			t.Errorf("validateSortBy(%q) = %v, want nil", sortBy, err)
+		}
+	}
+
+	if err := validateSortBy("Description"); err == nil {
+		t.Error("validateSortBy(\"Description\") = nil, want an error")
+	}
+}
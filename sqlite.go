@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	sqliteTableName = "lambda_functions"
+
+	// sqliteFirstSeenColumn and sqliteLastScannedColumn are the timestamps of the first and the latest scan
+	// that found the function, so that the functions which are gone can be found by their older last scan time
+	sqliteFirstSeenColumn   = "first_seen_at"
+	sqliteLastScannedColumn = "last_scanned_at"
+)
+
+// sqliteColumn is a column of the SQLite table, which is named after the json tag of the lambdaFunction field
+type sqliteColumn struct {
+	name      string
+	fieldName string
+	sqlType   string
+}
+
+// getSQLiteColumns returns the columns of the SQLite table, one for each lambdaFunction field.
+// The integer fields are stored as INTEGER and the other fields as TEXT, formatted the same way as in the CSV output
+func getSQLiteColumns() []sqliteColumn {
+	var columns []sqliteColumn
+
+	valueType := reflect.TypeOf(lambdaFunction{})
+	for i := range valueType.NumField() {
+		field := valueType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		sqlType := "TEXT"
+		switch field.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			sqlType = "INTEGER"
+		}
+
+		columns = append(columns, sqliteColumn{name: name, fieldName: field.Name, sqlType: sqlType})
+	}
+
+	return columns
+}
+
+// writeSQLite creates or opens the SQLite database at fileName and upserts each Lambda function keyed by its ARN,
+// so that the database keeps the history of repeated scans. The table is created if it doesn't exist,
+// and the columns added in newer versions of the program are added to an existing table
+func writeSQLite(ctx context.Context, fileName string, lambdaFunctionsList []lambdaFunction, scanTime time.Time) error {
+	db, err := sql.Open("sqlite", fileName)
+	if err != nil {
+		return fmt.Errorf("error when opening the SQLite database: %w", err)
+	}
+	defer db.Close()
+
+	columns := getSQLiteColumns()
+
+	err = createSQLiteTable(ctx, db, columns)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error when starting the SQLite transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, buildSQLiteUpsert(columns))
+	if err != nil {
+		return fmt.Errorf("error when preparing the SQLite upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	scannedAt := scanTime.UTC().Format(time.RFC3339)
+	for _, lambdaDetails := range lambdaFunctionsList {
+		record := lambdaDetails.getCellValues(nil)
+
+		values := append(record, scannedAt, scannedAt)
+		_, err := stmt.ExecContext(ctx, values...)
+		if err != nil {
+			return fmt.Errorf("error when writing the entry for function %q: %w", lambdaDetails.Name, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error when committing the SQLite transaction: %w", err)
+	}
+
+	return nil
+}
+
+// createSQLiteTable creates the table if it doesn't exist, with the ARN as the primary key,
+// and adds the columns which are missing from an existing table
+func createSQLiteTable(ctx context.Context, db *sql.DB, columns []sqliteColumn) error {
+	definitions := []string{}
+	for _, column := range columns {
+		definition := fmt.Sprintf("%s %s", column.name, column.sqlType)
+		if column.fieldName == "Arn" {
+			definition += " PRIMARY KEY"
+		}
+		definitions = append(definitions, definition)
+	}
+	definitions = append(definitions, sqliteFirstSeenColumn+" TEXT", sqliteLastScannedColumn+" TEXT")
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", sqliteTableName, strings.Join(definitions, ", ")))
+	if err != nil {
+		return fmt.Errorf("error when creating the SQLite table: %w", err)
+	}
+
+	existingColumns, err := getSQLiteTableColumns(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		if existingColumns[column.name] {
+			continue
+		}
+
+		_, err := db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", sqliteTableName, column.name, column.sqlType))
+		if err != nil {
+			return fmt.Errorf("error when adding the column %q to the SQLite table: %w", column.name, err)
+		}
+	}
+
+	return nil
+}
+
+// getSQLiteTableColumns returns the names of the columns of the existing table
+func getSQLiteTableColumns(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT name FROM pragma_table_info('%s')", sqliteTableName))
+	if err != nil {
+		return nil, fmt.Errorf("error when getting the columns of the SQLite table: %w", err)
+	}
+	defer rows.Close()
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var name string
+		err := rows.Scan(&name)
+		if err != nil {
+			return nil, fmt.Errorf("error when getting the columns of the SQLite table: %w", err)
+		}
+		columns[name] = true
+	}
+
+	return columns, rows.Err()
+}
+
+// buildSQLiteUpsert builds the statement that inserts a function, or updates all of its columns
+// and the last scan time if the ARN already exists. The first seen time is kept from the first insert
+func buildSQLiteUpsert(columns []sqliteColumn) string {
+	names := []string{}
+	updates := []string{}
+	for _, column := range columns {
+		names = append(names, column.name)
+		updates = append(updates, fmt.Sprintf("%s = excluded.%s", column.name, column.name))
+	}
+	names = append(names, sqliteFirstSeenColumn, sqliteLastScannedColumn)
+	updates = append(updates, fmt.Sprintf("%s = excluded.%s", sqliteLastScannedColumn, sqliteLastScannedColumn))
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ")
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (arn) DO UPDATE SET %s",
+		sqliteTableName, strings.Join(names, ", "), placeholders, strings.Join(updates, ", "))
+}
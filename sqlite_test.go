@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteSQLite(t *testing.T) {
+	ctx := context.Background()
+	fileName := filepath.Join(t.TempDir(), "functions.db")
+
+	lambdaFunctionsList := newTestFunctions()
+	for i := range lambdaFunctionsList {
+		lambdaFunctionsList[i].Arn = "arn:aws:lambda:" + lambdaFunctionsList[i].Region + ":123456789012:function:" + lambdaFunctionsList[i].Name
+		lambdaFunctionsList[i].MemorySize = 128
+	}
+
+	firstScan := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	err := writeSQLite(ctx, fileName, lambdaFunctionsList, firstScan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the second scan updates the existing rows keyed by the ARN instead of adding new ones
+	lambdaFunctionsList[0].Runtime = "python3.13"
+	secondScan := firstScan.Add(24 * time.Hour)
+	err = writeSQLite(ctx, fileName, lambdaFunctionsList, secondScan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, "SELECT name, runtime, memory_size, first_seen_at, last_scanned_at FROM "+sqliteTableName+" ORDER BY name")
+	if err != nil {
+		t.Fatalf("error when reading the table: %v", err)
+	}
+	defer rows.Close()
+
+	type row struct {
+		name, runtime          string
+		memorySize             int
+		firstSeen, lastScanned string
+	}
+	got := []row{}
+	for rows.Next() {
+		var r row
+		err := rows.Scan(&r.name, &r.runtime, &r.memorySize, &r.firstSeen, &r.lastScanned)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []row{
+		{name: "alpha", runtime: "python3.13", memorySize: 128, firstSeen: "2024-05-01T12:30:00Z", lastScanned: "2024-05-02T12:30:00Z"},
+		{name: "bravo", runtime: "nodejs20.x", memorySize: 128, firstSeen: "2024-05-01T12:30:00Z", lastScanned: "2024-05-02T12:30:00Z"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// regionStats contains the aggregated numbers of one region. The function count and the last invoke age
+// are added once the last invoke times are resolved, and the throttle and error counts while the workers are running
+type regionStats struct {
+	functionCount int
+	throttleCount int
+	errorCount    int
+
+	// lastInvokeAgeTotal is the sum of the time since the last invocation of the functions with a last invoke time,
+	// and lastInvokeAgeCount is the number of these functions
+	lastInvokeAgeTotal time.Duration
+	lastInvokeAgeCount int
+}
+
+// averageLastInvokeAge returns the average time since the last invocation, which is zero
+// if none of the functions has a last invoke time
+func (s regionStats) averageLastInvokeAge() time.Duration {
+	if s.lastInvokeAgeCount == 0 {
+		return 0
+	}
+
+	return s.lastInvokeAgeTotal / time.Duration(s.lastInvokeAgeCount)
+}
+
+// regionStatsCollector accumulates the stats of each region. It is safe for concurrent use by the workers
+type regionStatsCollector struct {
+	mu    sync.Mutex
+	stats map[string]*regionStats
+}
+
+// update calls fn with the stats of the region while holding the lock, creating the stats if they don't exist yet
+func (c *regionStatsCollector) update(region string, fn func(s *regionStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stats == nil {
+		c.stats = map[string]*regionStats{}
+	}
+	if c.stats[region] == nil {
+		c.stats[region] = &regionStats{}
+	}
+
+	fn(c.stats[region])
+}
+
+// addRegionThrottle counts one throttled API call in the region
+func (app *application) addRegionThrottle(region string) {
+	app.regionStats.update(region, func(s *regionStats) {
+		s.throttleCount++
+	})
+}
+
+// addRegionError counts one error in the region
+func (app *application) addRegionError(region string) {
+	app.regionStats.update(region, func(s *regionStats) {
+		s.errorCount++
+	})
+}
+
+// addFunctionStats counts the Lambda functions of each region and the time between their last invocation and now.
+// It must be called before the last invoke times are converted to the chosen time format.
+// The functions without a last invoke time, e.g. never invoked or inactive, are only counted
+func (app *application) addFunctionStats(lambdaFunctionsList []lambdaFunction, now time.Time) {
+	for _, lambdaDetails := range lambdaFunctionsList {
+		lastInvoked, err := time.Parse(lastInvokedTimeFormat, lambdaDetails.LastInvoked)
+
+		app.regionStats.update(lambdaDetails.Region, func(s *regionStats) {
+			s.functionCount++
+			if err == nil {
+				s.lastInvokeAgeTotal += now.Sub(lastInvoked)
+				s.lastInvokeAgeCount++
+			}
+		})
+	}
+}
+
+// getRegionStats returns a copy of the stats of each region keyed by region name.
+// The regions without any function, throttle, or error are included with zero stats
+func (app *application) getRegionStats() map[string]regionStats {
+	app.regionStats.mu.Lock()
+	defer app.regionStats.mu.Unlock()
+
+	stats := map[string]regionStats{}
+	for _, region := range app.regions {
+		stats[region] = regionStats{}
+	}
+	for region, s := range app.regionStats.stats {
+		stats[region] = *s
+	}
+
+	return stats
+}
+
+// printRegionStats writes the stats of each region as a table to w, in the same order as the regions
+func printRegionStats(w io.Writer, regions []string, stats map[string]regionStats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REGION\tFUNCTIONS\tTHROTTLES\tERRORS\tAVG LAST INVOKE AGE")
+	for _, region := range regions {
+		s := stats[region]
+
+		averageAge := "-"
+		if s.lastInvokeAgeCount > 0 {
+			averageAge = formatAge(s.averageLastInvokeAge())
+		}
+
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\n", region, s.functionCount, s.throttleCount, s.errorCount, averageAge)
+	}
+
+	return tw.Flush()
+}
+
+// formatAge formats the duration in days, or in hours if it is less than a day
+func formatAge(age time.Duration) string {
+	if age < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	}
+
+	return fmt.Sprintf("%dd", int(age.Hours()/24))
+}
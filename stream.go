@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// lastInvokeResolved is called by the last invoke results collector after the last invoke time of the Lambda function
+// at index is written in the lambdaFunctionsList slice. In streaming mode, the index is sent to the writer
+func (app *application) lastInvokeResolved(index int) {
+	app.lastInvokeProgress.Add(1)
+
+	if app.lastInvokeResults != nil {
+		app.lastInvokeResults <- index
+	}
+}
+
+// streamJSONL gets the last invoke time of all Lambda functions, and writes each function to w as a JSON line
+// as soon as its last invoke time is resolved, instead of waiting for all functions.
+// The functions dropped by -include-never-invoked and -max-age-days are not written
+func (app *application) streamJSONL(ctx context.Context, w io.Writer, lambdaFunctionsList []lambdaFunction, now time.Time) error {
+	results := make(chan int)
+	app.lastInvokeResults = results
+	defer func() { app.lastInvokeResults = nil }()
+
+	go func() {
+		jobs := app.generateJobs(ctx, lambdaFunctionsList)
+		app.getAllLambdaFunctionsLastInvokeTime(ctx, lambdaFunctionsList, jobs, app.stg.getLogsWorkers())
+		close(results)
+	}()
+
+	rw := newRecordWriter(w, outputOptions{format: outputFormatJSONL, jsonColumns: getDefaultColumns(app.stg)})
+
+	var writeErr error
+	for index := range results {
+		// keep receiving after a write error, so that the workers are not blocked
+		if writeErr != nil {
+			continue
+		}
+
+		lambdaDetails := lambdaFunctionsList[index]
+		if len(filterByLastInvoke([]lambdaFunction{lambdaDetails}, app.stg, now)) == 0 {
+			continue
+		}
+
+		if app.stg.withLastInvokedAge {
+			lambdaDetails.LastInvokedAge = formatLastInvokedAge(lambdaDetails.LastInvoked, now)
+		}
+		lambdaDetails.LastInvoked = formatLastInvoked(lambdaDetails.LastInvoked, app.stg.timeFormat, app.stg.lookbackDays, now)
+		if app.stg.formatLastModified {
+			lambdaDetails.LastModified = app.formatLastModified(lambdaDetails.LastModified)
+		}
+
+		err := rw.WriteRecord(lambdaDetails)
+		if err != nil {
+			writeErr = fmt.Errorf("error when writing the entry for function %q: %w", lambdaDetails.Name, err)
+		}
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+
+	return rw.Close()
+}
+
+// validateStreamOutput makes sure that streaming mode is only used with the options that don't need all functions
+// to be resolved before writing the output
+func validateStreamOutput(stg settings) error {
+	switch {
+	case stg.outputFormat != outputFormatJSONL:
+		return fmt.Errorf("streaming is only supported with the %q output format", outputFormatJSONL)
+	case isS3URI(stg.outputFileName):
+		return fmt.Errorf("streaming is not supported with S3 output")
+	case stg.sortBy != "":
+		return fmt.Errorf("streaming can't be used with -sort-by")
+	case stg.includeVersions:
+		return fmt.Errorf("streaming can't be used with -include-versions")
+	case stg.withTags || stg.withDestinations || stg.withConcurrency || stg.withLogRetention || stg.withRolePolicies || stg.withCostEstimate || stg.detailed:
+		return fmt.Errorf("streaming can't be used with -with-tags, -with-destinations, -with-concurrency, -with-log-retention, -with-role-policies, -with-cost-estimate, or -detailed")
+	default:
+		return nil
+	}
+}
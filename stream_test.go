@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// newStreamBenchmark returns an application with a fake CloudWatch Logs client that has a log stream
+// for each of the count functions, and the functions
+func newStreamBenchmark(count int) (*application, []lambdaFunction) {
+	client := &fakeCWLogsClient{logStreams: map[string][]types.LogStream{}}
+	lambdaFunctionsList := []lambdaFunction{}
+	for _, functionDetail := range functionConfigurations("function", count) {
+		lambdaDetails := newLambdaFunction(functionDetail, "us-east-1")
+		lambdaFunctionsList = append(lambdaFunctionsList, lambdaDetails)
+		client.logStreams[lambdaLogGroupPrefix+lambdaDetails.Name] = []types.LogStream{
+			{LastEventTimestamp: aws.Int64(time.Now().UnixMilli())},
+		}
+	}
+
+	app := newTestApplication()
+	app.stg.maxWorkers = 10
+	app.stg.outputFormat = outputFormatJSONL
+	app.stg.timeFormat = timeFormatRFC3339
+	app.cwLogsClients = map[string]cwLogsAPI{"us-east-1": client}
+
+	return app, lambdaFunctionsList
+}
+
+// BenchmarkStreamJSONL measures writing each function as soon as its last invoke time is resolved
+func BenchmarkStreamJSONL(b *testing.B) {
+	app, lambdaFunctionsList := newStreamBenchmark(5000)
+	b.ReportAllocs()
+
+	for b.Loop() {
+		err := app.streamJSONL(context.Background(), io.Discard, slices.Clone(lambdaFunctionsList), time.Now())
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkBufferedJSONL measures resolving the last invoke time of all functions before writing the output,
+// which is what the program does without -stream
+func BenchmarkBufferedJSONL(b *testing.B) {
+	app, lambdaFunctionsList := newStreamBenchmark(5000)
+	opts := outputOptions{format: outputFormatJSONL, jsonColumns: getDefaultColumns(app.stg)}
+	b.ReportAllocs()
+
+	for b.Loop() {
+		ctx := context.Background()
+		resolvedList := slices.Clone(lambdaFunctionsList)
+		app.getAllLambdaFunctionsLastInvokeTime(ctx, resolvedList, app.generateJobs(ctx, resolvedList), app.stg.getLogsWorkers())
+
+		now := time.Now()
+		resolvedList = filterByLastInvoke(resolvedList, app.stg, now)
+		formatLastInvokedTimes(resolvedList, app.stg.timeFormat, app.stg.lookbackDays, now)
+
+		err := writeOutput(io.Discard, opts, resolvedList)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	timeFormatRFC3339 = "rfc3339"
+	timeFormatEpoch   = "epoch"
+)
+
+// validateTimeFormat makes sure that the chosen time format is rfc3339, epoch, or a Go time layout
+func validateTimeFormat(timeFormat string) error {
+	switch timeFormat {
+	case timeFormatRFC3339, timeFormatEpoch:
+		return nil
+	}
+
+	// a layout without any time element would write the same value for all functions
+	if time.Unix(0, 0).Format(timeFormat) == timeFormat {
+		return fmt.Errorf("invalid time format %q, valid values are %q, %q, or a Go time layout, e.g. 2006-01-02 15:04",
+			timeFormat, timeFormatRFC3339, timeFormatEpoch)
+	}
+
+	return nil
+}
+
+// formatLastInvokedTimes converts the last invoke time of the Lambda functions to the chosen time format.
+// The values that are not a time, e.g. "-" for never invoked functions, are kept as they are.
+// It must be called right before writing the output, since the filters and the sorting expect lastInvokedTimeFormat
+func formatLastInvokedTimes(lambdaFunctionsList []lambdaFunction, timeFormat string, lookbackDays int, now time.Time) {
+	if timeFormat == timeFormatRFC3339 && lookbackDays == 0 {
+		return
+	}
+
+	for i := range lambdaFunctionsList {
+		lambdaFunctionsList[i].LastInvoked = formatLastInvoked(lambdaFunctionsList[i].LastInvoked, timeFormat, lookbackDays, now)
+	}
+}
+
+// formatLastInvoked converts one last invoke time from lastInvokedTimeFormat to the chosen time format.
+// With -lookback-days, the times older than lookbackDays days before now are written as inactiveLabel instead
+func formatLastInvoked(lastInvoked string, timeFormat string, lookbackDays int, now time.Time) string {
+	t, err := time.Parse(lastInvokedTimeFormat, lastInvoked)
+	if err != nil {
+		return lastInvoked
+	}
+
+	if lookbackDays > 0 && t.Before(now.AddDate(0, 0, -lookbackDays)) {
+		return inactiveLabel(lookbackDays)
+	}
+
+	return formatTime(t, timeFormat)
+}
+
+// inactiveLabel returns the last invoke value that is written for the functions which were not invoked in the last lookbackDays days
+func inactiveLabel(lookbackDays int) string {
+	return fmt.Sprintf("inactive (>%d days)", lookbackDays)
+}
+
+// setLastInvokedAges writes the time since the last invocation of the Lambda functions when -with-last-invoked-age is set.
+// It must be called before formatLastInvokedTimes, since the last invoke time is parsed with lastInvokedTimeFormat
+func (app *application) setLastInvokedAges(lambdaFunctionsList []lambdaFunction, now time.Time) {
+	if !app.stg.withLastInvokedAge {
+		return
+	}
+
+	for i := range lambdaFunctionsList {
+		lambdaFunctionsList[i].LastInvokedAge = formatLastInvokedAge(lambdaFunctionsList[i].LastInvoked, now)
+	}
+}
+
+// formatLastInvokedAge returns the time since the last invocation in the largest whole unit, e.g. 12d, 3h, or 5m,
+// which is rounded down. Never invoked functions are "never", and other values that are not a time,
+// e.g. with -skip-last-invoke, are "-"
+func formatLastInvokedAge(lastInvoked string, now time.Time) string {
+	if lastInvoked == "-" {
+		return "never"
+	}
+
+	t, err := time.Parse(lastInvokedTimeFormat, lastInvoked)
+	if err != nil {
+		return "-"
+	}
+
+	age := max(now.Sub(t), 0)
+	switch {
+	case age >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(age/(24*time.Hour)))
+	case age >= time.Hour:
+		return fmt.Sprintf("%dh", int(age/time.Hour))
+	default:
+		return fmt.Sprintf("%dm", int(age/time.Minute))
+	}
+}
+
+// formatLastModifiedTimes converts the last modified time of the Lambda functions from the Lambda API format
+// to the chosen time format and the output timezone when -format-last-modified is set, so that both times
+// are written the same way. Like formatLastInvokedTimes, it must be called right before writing the output
+func (app *application) formatLastModifiedTimes(lambdaFunctionsList []lambdaFunction) {
+	if !app.stg.formatLastModified {
+		return
+	}
+
+	for i := range lambdaFunctionsList {
+		lambdaFunctionsList[i].LastModified = app.formatLastModified(lambdaFunctionsList[i].LastModified)
+	}
+}
+
+// formatLastModified converts one last modified time from lastModifiedTimeFormat to the chosen time format.
+// The values that can't be parsed are kept as they are
+func (app *application) formatLastModified(lastModified string) string {
+	t, err := time.Parse(lastModifiedTimeFormat, lastModified)
+	if err != nil {
+		return lastModified
+	}
+
+	return formatTime(app.inOutputTimezone(t), app.stg.timeFormat)
+}
+
+// formatTime formats t in the chosen time format, where rfc3339 is lastInvokedTimeFormat
+func formatTime(t time.Time, timeFormat string) string {
+	switch timeFormat {
+	case timeFormatRFC3339:
+		return t.Format(lastInvokedTimeFormat)
+	case timeFormatEpoch:
+		return strconv.FormatInt(t.Unix(), 10)
+	default:
+		return t.Format(timeFormat)
+	}
+}
+
+// inOutputTimezone converts t to UTC if -utc is set, or to the local timezone otherwise,
+// so that the times taken from the logs and from the metrics have the same offset
+func (app *application) inOutputTimezone(t time.Time) time.Time {
+	if app.stg.utc {
+		return t.UTC()
+	}
+
+	return t.Local()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatLastInvoked(t *testing.T) {
+	lastInvoked := "2024-05-01T12:30:00+02:00"
+
+	tests := []struct {
+		name        string
+		lastInvoked string
+		timeFormat  string
+		want        string
+	}{
+		{name: "rfc3339", lastInvoked: lastInvoked, timeFormat: timeFormatRFC3339, want: "2024-05-01T12:30:00+02:00"},
+		{name: "epoch", lastInvoked: lastInvoked, timeFormat: timeFormatEpoch, want: "1714559400"},
+		{name: "Go layout", lastInvoked: lastInvoked, timeFormat: "2006-01-02 15:04", want: "2024-05-01 12:30"},
+		{name: "never invoked", lastInvoked: "-", timeFormat: timeFormatEpoch, want: "-"},
+		{name: "skipped", lastInvoked: lastInvokedSkipped, timeFormat: timeFormatEpoch, want: lastInvokedSkipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatLastInvoked(tt.lastInvoked, tt.timeFormat, 0, time.Now()); got != tt.want {
+				t.Errorf("formatLastInvoked(%q, %q) = %q, want %q", tt.lastInvoked, tt.timeFormat, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTimeFormat(t *testing.T) {
+	for _, timeFormat := range []string{timeFormatRFC3339, timeFormatEpoch, "2006-01-02 15:04", time.Kitchen} {
+		if err := validateTimeFormat(timeFormat); err != nil {
+			t.Errorf("validateTimeFormat(%q) = %v, want nil", timeFormat, err)
+		}
+	}
+
+	// a layout without any time element would write the same value for all functions
+	for _, timeFormat := range []string{"unix", "yyyy-mm-dd", ""} {
+		if err := validateTimeFormat(timeFormat); err == nil {
+			t.Errorf("validateTimeFormat(%q) = nil, want an error", timeFormat)
+		}
+	}
+}
+
+func TestInOutputTimezone(t *testing.T) {
+	// the local timezone is pinned, so that the test doesn't depend on the timezone of the machine
+	local := time.Local
+	time.Local = time.FixedZone("UTC+9", 9*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	epoch := time.Unix(1714566600, 0)
+
+	tests := []struct {
+		name string
+		utc  bool
+		want string
+	}{
+		{name: "utc", utc: true, want: "2024-05-01T12:30:00+00:00"},
+		{name: "local", utc: false, want: "2024-05-01T21:30:00+09:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication()
+			app.stg.utc = tt.utc
+
+			if got := app.inOutputTimezone(epoch).Format(lastInvokedTimeFormat); got != tt.want {
+				t.Errorf("inOutputTimezone() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLastModified(t *testing.T) {
+	tests := []struct {
+		name         string
+		lastModified string
+		timeFormat   string
+		want         string
+	}{
+		{name: "rfc3339 in utc", lastModified: "2024-05-01T21:30:00.000+0900", timeFormat: timeFormatRFC3339, want: "2024-05-01T12:30:00+00:00"},
+		{name: "epoch", lastModified: "2024-05-01T12:30:00.000+0000", timeFormat: timeFormatEpoch, want: "1714566600"},
+		{name: "Go layout", lastModified: "2024-05-01T12:30:00.000+0000", timeFormat: "2006-01-02 15:04", want: "2024-05-01 12:30"},
+		{name: "unparseable value is kept", lastModified: "-", timeFormat: timeFormatEpoch, want: "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication()
+			app.stg.timeFormat = tt.timeFormat
+
+			if got := app.formatLastModified(tt.lastModified); got != tt.want {
+				t.Errorf("formatLastModified(%q) = %q, want %q", tt.lastModified, got, tt.want)
+			}
+		})
+	}
+
+	// the API format is kept unless -format-last-modified is set
+	app := newTestApplication()
+	app.stg.timeFormat = timeFormatEpoch
+	lambdaFunctionsList := []lambdaFunction{{LastModified: "2024-05-01T12:30:00.000+0000"}}
+	app.formatLastModifiedTimes(lambdaFunctionsList)
+	if lambdaFunctionsList[0].LastModified != "2024-05-01T12:30:00.000+0000" {
+		t.Errorf("last modified = %q, want it unchanged", lambdaFunctionsList[0].LastModified)
+	}
+
+	app.stg.formatLastModified = true
+	app.formatLastModifiedTimes(lambdaFunctionsList)
+	if lambdaFunctionsList[0].LastModified != "1714566600" {
+		t.Errorf("last modified = %q, want 1714566600", lambdaFunctionsList[0].LastModified)
+	}
+}
+
+func TestFormatLastInvokedAge(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		lastInvoked string
+		want        string
+	}{
+		{name: "days are rounded down", lastInvoked: "2024-05-01T12:30:00+00:00", want: "8d"},
+		{name: "exactly one day", lastInvoked: "2024-05-09T12:00:00+00:00", want: "1d"},
+		{name: "hours are rounded down", lastInvoked: "2024-05-10T00:01:00+00:00", want: "11h"},
+		{name: "minutes are rounded down", lastInvoked: "2024-05-10T11:30:30+00:00", want: "29m"},
+		{name: "other timezone", lastInvoked: "2024-05-10T20:00:00+09:00", want: "1h"},
+		{name: "in the future", lastInvoked: "2024-05-10T12:05:00+00:00", want: "0m"},
+		{name: "never invoked", lastInvoked: "-", want: "never"},
+		{name: "skipped", lastInvoked: lastInvokedSkipped, want: "-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatLastInvokedAge(tt.lastInvoked, now); got != tt.want {
+				t.Errorf("formatLastInvokedAge(%q) = %q, want %q", tt.lastInvoked, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/smithy-go/middleware"
+	"go.uber.org/zap"
+)
+
+// phaseDuration is how long one phase of the run took, e.g. listing the functions
+type phaseDuration struct {
+	name     string
+	duration time.Duration
+}
+
+// recordPhase stores the duration of the phase that started at startTime, so that it can be reported
+// in the performance summary at the end of the run. It must only be called from the main goroutine
+func (app *application) recordPhase(name string, startTime time.Time) {
+	duration := time.Since(startTime)
+	app.phaseDurations = append(app.phaseDurations, phaseDuration{name: name, duration: duration})
+
+	app.logger.Debugw("phase finished",
+		zap.String("phase", name),
+		zap.Duration("duration", duration),
+	)
+}
+
+// addAPICallCounter adds a middleware to all AWS service clients that counts every API call attempt,
+// including the retries
+func (app *application) addAPICallCounter(stack *middleware.Stack) error {
+	return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("CountAPICalls",
+		func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			app.apiCallCount.Add(1)
+			return next.HandleFinalize(ctx, in)
+		},
+	), middleware.After)
+}
+
+// logPerformanceSummary logs the total duration of the run at info level, and the duration of each phase
+// and the number of API calls per second at debug level
+func (app *application) logPerformanceSummary(totalDuration time.Duration) {
+	app.logger.Infow("run finished",
+		zap.Duration("total_duration", totalDuration),
+	)
+
+	apiCallCount := app.apiCallCount.Load()
+	apiCallsPerSecond := 0.0
+	if totalDuration > 0 {
+		apiCallsPerSecond = float64(apiCallCount) / totalDuration.Seconds()
+	}
+
+	fields := []any{
+		zap.Int64("api_calls", apiCallCount),
+		zap.Float64("api_calls_per_second", apiCallsPerSecond),
+	}
+	for _, phase := range app.phaseDurations {
+		fields = append(fields, zap.Duration(phase.name, phase.duration))
+	}
+	app.logger.Debugw("performance summary", fields...)
+}
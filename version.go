@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// version, commit, and buildDate are set at build time with -ldflags, e.g.
+// -ldflags "-X main.version=v1.2.0 -X main.commit=abc1234 -X main.buildDate=2025-01-13T12:00:00Z".
+// The ones that are not set are read from the build info embedded by the Go toolchain
+var (
+	version   string
+	commit    string
+	buildDate string
+)
+
+// unknownBuildInfo is the value of the build details that are neither set with -ldflags nor in the build info
+const unknownBuildInfo = "unknown"
+
+// buildInfo contains the details of the build that are printed with -version
+type buildInfo struct {
+	version   string
+	commit    string
+	buildDate string
+}
+
+// getBuildInfo returns the version, the commit, and the build date of the binary.
+// The values set with -ldflags take precedence over the module version and the VCS details of the build info,
+// which are only available when the binary is built with go install or from a git checkout
+func getBuildInfo() buildInfo {
+	info := buildInfo{
+		version:   unknownBuildInfo,
+		commit:    unknownBuildInfo,
+		buildDate: unknownBuildInfo,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.version = bi.Main.Version
+		}
+
+		modified := false
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				info.commit = setting.Value
+			case "vcs.time":
+				info.buildDate = setting.Value
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+
+		if modified && info.commit != unknownBuildInfo {
+			info.commit += "-dirty"
+		}
+	}
+
+	if version != "" {
+		info.version = version
+	}
+	if commit != "" {
+		info.commit = commit
+	}
+	if buildDate != "" {
+		info.buildDate = buildDate
+	}
+
+	return info
+}
+
+// String formats the build info as printed with -version
+func (b buildInfo) String() string {
+	return fmt.Sprintf("alli-lister %s (commit %s, built %s)", b.version, b.commit, b.buildDate)
+}
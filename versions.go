@@ -0,0 +1,135 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+)
+
+// latestVersion is the version of the unpublished function code
+const latestVersion = "$LATEST"
+
+// compareVersions orders $LATEST before the published versions, which are ordered by their number
+func compareVersions(a string, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == latestVersion {
+		return -1
+	}
+	if b == latestVersion {
+		return 1
+	}
+
+	aNumber, aErr := strconv.Atoi(a)
+	bNumber, bErr := strconv.Atoi(b)
+	if aErr != nil || bErr != nil {
+		return cmp.Compare(a, b)
+	}
+
+	return cmp.Compare(aNumber, bNumber)
+}
+
+// getLatestVersions returns the $LATEST rows of the Lambda functions, which is one row per function
+// when the published versions are also listed
+func getLatestVersions(lambdaFunctionsList []lambdaFunction) []lambdaFunction {
+	latestList := []lambdaFunction{}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		if lambdaDetails.Version == latestVersion {
+			latestList = append(latestList, lambdaDetails)
+		}
+	}
+
+	return latestList
+}
+
+// copyLastInvokedToVersions sets the last invoke time and the invoke count of every version row to the ones
+// of the $LATEST row of the same function, since all versions write to the same log group and metrics
+func copyLastInvokedToVersions(latestList []lambdaFunction, lambdaFunctionsList []lambdaFunction) {
+	latestByName := map[string]lambdaFunction{}
+	for _, lambdaDetails := range latestList {
+		latestByName[lambdaDetails.Region+"/"+lambdaDetails.Name] = lambdaDetails
+	}
+
+	for i := range lambdaFunctionsList {
+		if latest, ok := latestByName[lambdaFunctionsList[i].Region+"/"+lambdaFunctionsList[i].Name]; ok {
+			lambdaFunctionsList[i].LastInvoked = latest.LastInvoked
+			lambdaFunctionsList[i].InvokeCount = latest.InvokeCount
+		}
+	}
+}
+
+// unqualifiedFunctionArn removes the version or alias from the function ARN,
+// e.g. arn:aws:lambda:us-east-1:123456789012:function:my-function:1 becomes
+// arn:aws:lambda:us-east-1:123456789012:function:my-function
+func unqualifiedFunctionArn(functionArn string) string {
+	parts := strings.Split(functionArn, ":")
+	if len(parts) > 7 {
+		return strings.Join(parts[:7], ":")
+	}
+
+	return functionArn
+}
+
+// getAllLambdaFunctionsAliases lists the aliases of the Lambda functions concurrently using runPerFunction.
+// The aliases are listed once per function, and written in the Aliases column of every version they point to
+func (app *application) getAllLambdaFunctionsAliases(ctx context.Context, lambdaFunctionsList []lambdaFunction, maxWorkers int) {
+	app.logger.Info("getting aliases for all lambda functions")
+
+	latestList := getLatestVersions(lambdaFunctionsList)
+	jobs := app.generateJobs(ctx, latestList)
+
+	// aliasesByFunction is keyed by region/function name, then by version, and is only written by the results
+	// of runPerFunction, which are applied by the calling goroutine
+	aliasesByFunction := map[string]map[string][]string{}
+	app.runPerFunction(ctx, latestList, jobs, maxWorkers, "ListAliases", func(ctx context.Context, currentJob job) (func(*lambdaFunction), error) {
+		versionAliases, err := app.getLambdaFunctionAliases(ctx, currentJob)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(lambdaDetails *lambdaFunction) {
+			aliasesByFunction[lambdaDetails.Region+"/"+lambdaDetails.Name] = versionAliases
+		}, nil
+	}, nil)
+
+	for i := range lambdaFunctionsList {
+		versionAliases := aliasesByFunction[lambdaFunctionsList[i].Region+"/"+lambdaFunctionsList[i].Name]
+		if names := versionAliases[lambdaFunctionsList[i].Version]; len(names) > 0 {
+			lambdaFunctionsList[i].Aliases = strings.Join(names, ";")
+		}
+	}
+
+	app.logger.Info("got aliases for all lambda functions")
+}
+
+// getLambdaFunctionAliases lists the aliases of the Lambda function of the job, keyed by the version they point to
+func (app *application) getLambdaFunctionAliases(ctx context.Context, currentJob job) (map[string][]string, error) {
+	lambdaClient := app.getLambdaClient(currentJob.region)
+
+	versionAliases := map[string][]string{}
+	in := &lambda.ListAliasesInput{
+		FunctionName: aws.String(currentJob.functionName),
+	}
+
+	for {
+		out, err := lambdaClient.ListAliases(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, alias := range out.Aliases {
+			version := aws.ToString(alias.FunctionVersion)
+			versionAliases[version] = append(versionAliases[version], aws.ToString(alias.Name))
+		}
+
+		if out.NextMarker == nil {
+			return versionAliases, nil
+		}
+		in.Marker = out.NextMarker
+	}
+}
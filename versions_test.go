@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/smithy-go"
+)
+
+// functionVersion returns the configuration of one version of the function
+func functionVersion(name string, version string) lambdatypes.FunctionConfiguration {
+	return lambdatypes.FunctionConfiguration{
+		FunctionName: aws.String(name),
+		FunctionArn:  aws.String("arn:aws:lambda:us-east-1:123456789012:function:" + name + ":" + version),
+		Version:      aws.String(version),
+	}
+}
+
+func TestGetAllLambdaFunctionsDetailsAllVersions(t *testing.T) {
+	// the versions of the same function are split across the pages and are not in order
+	client := &fakeLambdaClient{
+		pages: map[string]*lambda.ListFunctionsOutput{
+			"": {
+				Functions:  []lambdatypes.FunctionConfiguration{functionVersion("bravo", "10"), functionVersion("alpha", latestVersion)},
+				NextMarker: aws.String("marker-1"),
+			},
+			"marker-1": {
+				Functions:  []lambdatypes.FunctionConfiguration{functionVersion("bravo", "2"), functionVersion("bravo", latestVersion)},
+				NextMarker: aws.String("marker-2"),
+			},
+			"marker-2": {
+				Functions: []lambdatypes.FunctionConfiguration{functionVersion("alpha", "1"), functionVersion("bravo", "1")},
+			},
+		},
+	}
+
+	app := newTestApplication()
+	app.stg.includeVersions = true
+	app.regions = []string{"us-east-1"}
+	app.lambdaClients = map[string]lambdaAPI{"us-east-1": client}
+
+	lambdaFunctionsList, err := app.getAllLambdaFunctionsDetails(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, input := range client.inputs {
+		if input.FunctionVersion != lambdatypes.FunctionVersionAll {
+			t.Errorf("ListFunctions is called with FunctionVersion %q, want ALL", input.FunctionVersion)
+		}
+	}
+	if len(client.inputs) != 3 {
+		t.Errorf("ListFunctions is called %d times, want 3", len(client.inputs))
+	}
+
+	rows := []string{}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		rows = append(rows, lambdaDetails.Name+":"+lambdaDetails.Version)
+	}
+	want := []string{"alpha:$LATEST", "alpha:1", "bravo:$LATEST", "bravo:1", "bravo:2", "bravo:10"}
+	if !slices.Equal(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+
+	// the last invoke time of $LATEST is copied to all versions of the same function
+	latestList := getLatestVersions(lambdaFunctionsList)
+	if len(latestList) != 2 {
+		t.Fatalf("got %d $LATEST rows, want 2", len(latestList))
+	}
+	latestList[0].LastInvoked = "2024-05-01T12:30:00+00:00"
+	latestList[1].LastInvoked = "2024-04-01T12:30:00+00:00"
+	copyLastInvokedToVersions(latestList, lambdaFunctionsList)
+	for _, lambdaDetails := range lambdaFunctionsList {
+		wantLastInvoked := latestList[1].LastInvoked
+		if lambdaDetails.Name == "alpha" {
+			wantLastInvoked = latestList[0].LastInvoked
+		}
+		if lambdaDetails.LastInvoked != wantLastInvoked {
+			t.Errorf("last invoke time of %s:%s = %q, want %q", lambdaDetails.Name, lambdaDetails.Version, lambdaDetails.LastInvoked, wantLastInvoked)
+		}
+	}
+}
+
+func TestUnqualifiedFunctionArn(t *testing.T) {
+	tests := []struct {
+		functionArn string
+		want        string
+	}{
+		{functionArn: "arn:aws:lambda:us-east-1:123456789012:function:my-function:1", want: "arn:aws:lambda:us-east-1:123456789012:function:my-function"},
+		{functionArn: "arn:aws:lambda:us-east-1:123456789012:function:my-function:$LATEST", want: "arn:aws:lambda:us-east-1:123456789012:function:my-function"},
+		{functionArn: "arn:aws:lambda:us-east-1:123456789012:function:my-function", want: "arn:aws:lambda:us-east-1:123456789012:function:my-function"},
+	}
+
+	for _, tt := range tests {
+		if got := unqualifiedFunctionArn(tt.functionArn); got != tt.want {
+			t.Errorf("unqualifiedFunctionArn(%q) = %q, want %q", tt.functionArn, got, tt.want)
+		}
+	}
+}
+
+// fakeAliasLister returns the aliases keyed by function name, or the error of the function if it is set
+type fakeAliasLister struct {
+	lambdaAPI
+
+	aliases map[string][]lambdatypes.AliasConfiguration
+	errs    map[string]error
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeAliasLister) ListAliases(ctx context.Context, params *lambda.ListAliasesInput, optFns ...func(*lambda.Options)) (*lambda.ListAliasesOutput, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+
+	functionName := aws.ToString(params.FunctionName)
+	if err := f.errs[functionName]; err != nil {
+		return nil, err
+	}
+
+	return &lambda.ListAliasesOutput{Aliases: f.aliases[functionName]}, nil
+}
+
+func TestGetAllLambdaFunctionsAliases(t *testing.T) {
+	client := &fakeAliasLister{
+		aliases: map[string][]lambdatypes.AliasConfiguration{
+			"alpha": {
+				{Name: aws.String("prod"), FunctionVersion: aws.String("1")},
+				{Name: aws.String("stable"), FunctionVersion: aws.String("1")},
+				{Name: aws.String("dev"), FunctionVersion: aws.String(latestVersion)},
+			},
+		},
+		errs: map[string]error{"bravo": &smithy.GenericAPIError{Code: "ResourceNotFoundException", Message: "the function doesn't exist"}},
+	}
+
+	lambdaFunctionsList := []lambdaFunction{}
+	for _, functionDetail := range []lambdatypes.FunctionConfiguration{
+		functionVersion("alpha", latestVersion), functionVersion("alpha", "1"), functionVersion("alpha", "2"),
+		functionVersion("bravo", latestVersion), functionVersion("bravo", "1"),
+	} {
+		lambdaFunctionsList = append(lambdaFunctionsList, newLambdaFunction(functionDetail, "us-east-1"))
+	}
+
+	app := newTestApplication()
+	app.lambdaClients = map[string]lambdaAPI{"us-east-1": client}
+	app.getAllLambdaFunctionsAliases(context.Background(), lambdaFunctionsList, 2)
+
+	// the aliases are listed once per function, not per version
+	if client.calls != 2 {
+		t.Errorf("ListAliases is called %d times, want 2", client.calls)
+	}
+
+	want := []string{"alpha:$LATEST:dev", "alpha:1:prod;stable", "alpha:2:-", "bravo:$LATEST:-", "bravo:1:-"}
+	got := []string{}
+	for _, lambdaDetails := range lambdaFunctionsList {
+		got = append(got, lambdaDetails.Name+":"+lambdaDetails.Version+":"+lambdaDetails.Aliases)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("aliases = %q, want %q", got, want)
+	}
+
+	if runErrors := app.getErrors(); len(runErrors) != 1 {
+		t.Errorf("recorded %d errors, want 1: %+v", len(runErrors), runErrors)
+	}
+}
+
+func TestGetAllLambdaFunctionsAliasesFatalError(t *testing.T) {
+	expiredToken := &smithy.GenericAPIError{Code: "ExpiredTokenException", Message: "the security token has expired"}
+	client := &fakeAliasLister{errs: map[string]error{}}
+
+	lambdaFunctionsList := []lambdaFunction{}
+	for _, functionDetail := range functionConfigurations("function", 50) {
+		client.errs[aws.ToString(functionDetail.FunctionName)] = expiredToken
+		lambdaFunctionsList = append(lambdaFunctionsList, newLambdaFunction(functionDetail, "us-east-1"))
+	}
+
+	app := newTestApplication()
+	app.lambdaClients = map[string]lambdaAPI{"us-east-1": client}
+	app.getAllLambdaFunctionsAliases(context.Background(), lambdaFunctionsList, 1)
+
+	// the expired credentials stop listing the remaining functions, so only a few of them are called
+	if runErrors := app.getErrors(); len(runErrors) == 0 || client.calls == len(lambdaFunctionsList) {
+		t.Errorf("recorded %d errors after %d calls, want the calls to stop after the fatal error", len(runErrors), client.calls)
+	}
+}
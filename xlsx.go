@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	xlsxSheetName = "Lambda Functions"
+
+	// xlsxMaxColumnWidth caps the width of the auto-sized columns, so that long values
+	// like the description or the layers don't make a column wider than the screen
+	xlsxMaxColumnWidth = 80
+)
+
+// writeXLSX writes an Excel workbook with a single sheet of the chosen columns. The title row is bold and frozen,
+// the numeric columns are written as numbers, and the width of each column is set from its longest value
+func writeXLSX(w io.Writer, opts outputOptions, lambdaFunctionsList []lambdaFunction) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	err := f.SetSheetName(f.GetSheetName(0), xlsxSheetName)
+	if err != nil {
+		return fmt.Errorf("error when creating the sheet: %w", err)
+	}
+
+	titles := lambdaFunction{}.getTitleFields(opts.columns)
+	columnWidths := make([]int, len(titles))
+
+	titleRow := make([]any, len(titles))
+	for i, title := range titles {
+		titleRow[i] = title
+		columnWidths[i] = utf8.RuneCountInString(title)
+	}
+
+	err = f.SetSheetRow(xlsxSheetName, "A1", &titleRow)
+	if err != nil {
+		return fmt.Errorf("error when writing title: %w", err)
+	}
+
+	for i, lambdaDetails := range lambdaFunctionsList {
+		cells := lambdaDetails.getCellValues(opts.columns)
+
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return err
+		}
+
+		err = f.SetSheetRow(xlsxSheetName, cell, &cells)
+		if err != nil {
+			return fmt.Errorf("error when writing the entry for function %q: %w", lambdaDetails.Name, err)
+		}
+
+		for j, value := range cells {
+			columnWidths[j] = max(columnWidths[j], utf8.RuneCountInString(fmt.Sprint(value)))
+		}
+	}
+
+	err = styleXLSXSheet(f, len(titles), columnWidths)
+	if err != nil {
+		return err
+	}
+
+	err = f.Write(w)
+	if err != nil {
+		return fmt.Errorf("error when writing the xlsx file: %w", err)
+	}
+
+	return nil
+}
+
+// styleXLSXSheet makes the title row bold, freezes it so that it stays visible when scrolling,
+// and sets the width of each column
+func styleXLSXSheet(f *excelize.File, columnCount int, columnWidths []int) error {
+	if columnCount == 0 {
+		return nil
+	}
+
+	lastTitleCell, err := excelize.CoordinatesToCellName(columnCount, 1)
+	if err != nil {
+		return err
+	}
+
+	boldStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return fmt.Errorf("error when creating the title style: %w", err)
+	}
+
+	err = f.SetCellStyle(xlsxSheetName, "A1", lastTitleCell, boldStyle)
+	if err != nil {
+		return fmt.Errorf("error when styling the title row: %w", err)
+	}
+
+	err = f.SetPanes(xlsxSheetName, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	})
+	if err != nil {
+		return fmt.Errorf("error when freezing the title row: %w", err)
+	}
+
+	for i, width := range columnWidths {
+		columnName, err := excelize.ColumnNumberToName(i + 1)
+		if err != nil {
+			return err
+		}
+
+		// add some padding, since the width is in characters of the default font
+		err = f.SetColWidth(xlsxSheetName, columnName, columnName, float64(min(width+2, xlsxMaxColumnWidth)))
+		if err != nil {
+			return fmt.Errorf("error when setting the width of column %s: %w", columnName, err)
+		}
+	}
+
+	return nil
+}
+
+// getCellValues returns the values of the chosen columns in the same order as getRecordFields.
+// The integer fields are kept as numbers so that they can be summed and sorted in a spreadsheet,
+// and the other fields are formatted the same way as in the CSV output
+func (l lambdaFunction) getCellValues(columns []string) []any {
+	if len(columns) == 0 {
+		columns = getColumnNames()
+	}
+
+	record := l.getRecordFields(columns)
+	cells := make([]any, len(columns))
+
+	value := reflect.ValueOf(l)
+	for i, column := range columns {
+		fieldValue := value.FieldByName(column)
+		switch fieldValue.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			cells[i] = fieldValue.Int()
+		default:
+			cells[i] = record[i]
+		}
+	}
+
+	return cells
+}